@@ -19,6 +19,9 @@ func main() {
 		Net:                  "tcp",
 		AllowNativePasswords: true,
 		ParseTime:            true,
+		// Some migrations run several statements, arguments are still sent
+		// apart from the queries
+		MultiStatements: true,
 	}
 
 	db, err := app.NewMySqlStorage(cfg)
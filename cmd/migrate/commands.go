@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go-restaurant-management/internal/app"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+type command struct {
+	name string
+	// steps limits up and down, 0 runs all of them
+	steps int
+	// version is the target of goto and force
+	version int
+	// migrationName is the name given to create
+	migrationName string
+}
+
+func parseCommand(args []string) (command, error) {
+	if len(args) == 0 {
+		return command{}, errors.New("missing command")
+	}
+	cmd := command{name: args[0]}
+	args = args[1:]
+
+	switch cmd.name {
+	case "up", "down":
+		if len(args) > 1 {
+			return command{}, fmt.Errorf("%s takes at most one argument", cmd.name)
+		}
+		if len(args) == 1 {
+			steps, err := strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return command{}, fmt.Errorf("%s takes a positive number of migrations, got %q", cmd.name, args[0])
+			}
+			cmd.steps = steps
+		}
+	case "goto", "force":
+		if len(args) != 1 {
+			return command{}, fmt.Errorf("%s takes a version", cmd.name)
+		}
+		version, err := strconv.Atoi(args[0])
+		minimum := 0
+		if cmd.name == "force" {
+			minimum = -1
+		}
+		if err != nil || version < minimum {
+			return command{}, fmt.Errorf("invalid version %q", args[0])
+		}
+		cmd.version = version
+	case "version", "status":
+		if len(args) != 0 {
+			return command{}, fmt.Errorf("%s takes no arguments", cmd.name)
+		}
+	case "create":
+		if len(args) != 1 {
+			return command{}, errors.New("create takes a name")
+		}
+		cmd.migrationName = strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(args[0])))
+		if !migrationNamePattern.MatchString(cmd.migrationName) {
+			return command{}, fmt.Errorf("invalid name %q, use letters, digits and underscores", args[0])
+		}
+	default:
+		return command{}, fmt.Errorf("unknown command %q", cmd.name)
+	}
+
+	return cmd, nil
+}
+
+func run(m *migrate.Migrate, cmd command, dryRun bool) error {
+	switch cmd.name {
+	case "version":
+		return printVersion(m)
+	case "status":
+		return printStatus(m)
+	case "force":
+		if dryRun {
+			log.Printf("Migration: Would force version %d", cmd.version)
+			return nil
+		}
+		if err := m.Force(cmd.version); err != nil {
+			return err
+		}
+	default:
+		if dryRun {
+			return printPlan(m, cmd)
+		}
+		if err := apply(m, cmd); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Migration: Successfully executed")
+	return nil
+}
+
+func apply(m *migrate.Migrate, cmd command) error {
+	switch {
+	case cmd.name == "goto":
+		return m.Migrate(uint(cmd.version))
+	case cmd.name == "up" && cmd.steps == 0:
+		return m.Up()
+	case cmd.name == "up":
+		return m.Steps(cmd.steps)
+	case cmd.steps == 0:
+		return m.Down()
+	default:
+		return m.Steps(-cmd.steps)
+	}
+}
+
+// currentVersion returns -1 when no migration was applied.
+func currentVersion(m *migrate.Migrate) (int, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return -1, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return int(version), dirty, nil
+}
+
+func printVersion(m *migrate.Migrate) error {
+	version, dirty, err := currentVersion(m)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version == -1:
+		fmt.Println("no migration applied")
+	case dirty:
+		fmt.Printf("%d (dirty)\n", version)
+	default:
+		fmt.Println(version)
+	}
+	return nil
+}
+
+func printStatus(m *migrate.Migrate) error {
+	current, dirty, err := currentVersion(m)
+	if err != nil {
+		return err
+	}
+
+	src, err := app.NewMigrationSource()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	versions, err := app.MigrationVersions(src)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, version := range versions {
+		name, err := migrationName(src, version)
+		if err != nil {
+			return err
+		}
+
+		status := "pending"
+		if int(version) == current && dirty {
+			status = "dirty"
+		} else if int(version) <= current {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", version, name, status)
+	}
+	return w.Flush()
+}
+
+// printPlan prints the SQL that up, down or goto would run.
+func printPlan(m *migrate.Migrate, cmd command) error {
+	current, dirty, err := currentVersion(m)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d, fix it and run force first", current)
+	}
+
+	src, err := app.NewMigrationSource()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	steps, err := plan(src, current, cmd)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return migrate.ErrNoChange
+	}
+
+	for _, step := range steps {
+		read, direction := src.ReadUp, "up"
+		if !step.up {
+			read, direction = src.ReadDown, "down"
+		}
+
+		body, name, err := read(step.version)
+		if err != nil {
+			return err
+		}
+		query, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("-- %d %s (%s)\n%s\n\n", step.version, name, direction, strings.TrimSpace(string(query)))
+	}
+	return nil
+}
+
+type planStep struct {
+	version uint
+	up      bool
+}
+
+// plan lists the migrations cmd runs from the current version, -1 when no
+// migration was applied, in the order they run.
+func plan(src source.Driver, current int, cmd command) ([]planStep, error) {
+	versions, err := app.MigrationVersions(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending, applied []uint
+	for _, version := range versions {
+		if int(version) > current {
+			pending = append(pending, version)
+		} else {
+			applied = append(applied, version)
+		}
+	}
+	slices.Reverse(applied)
+
+	switch cmd.name {
+	case "up":
+		if cmd.steps > 0 && cmd.steps < len(pending) {
+			pending = pending[:cmd.steps]
+		}
+		return planSteps(pending, true), nil
+	case "down":
+		if cmd.steps > 0 && cmd.steps < len(applied) {
+			applied = applied[:cmd.steps]
+		}
+		return planSteps(applied, false), nil
+	case "goto":
+		target := uint(cmd.version)
+		if !slices.Contains(versions, target) {
+			return nil, fmt.Errorf("version %d doesn't exist", target)
+		}
+		if cmd.version > current {
+			return planSteps(pending[:slices.Index(pending, target)+1], true), nil
+		}
+		// The target itself stays applied
+		index := slices.Index(applied, target)
+		return planSteps(applied[:index], false), nil
+	}
+
+	return nil, fmt.Errorf("%s has no plan", cmd.name)
+}
+
+func planSteps(versions []uint, up bool) []planStep {
+	steps := make([]planStep, len(versions))
+	for i, version := range versions {
+		steps[i] = planStep{version: version, up: up}
+	}
+	return steps
+}
+
+func migrationName(src source.Driver, version uint) (string, error) {
+	body, name, err := src.ReadUp(version)
+	if err != nil {
+		return "", err
+	}
+	body.Close()
+	return name, nil
+}
+
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// createMigration adds empty up and down files named like the existing ones.
+// They are embedded the next time the binaries are built.
+func createMigration(dir string, name string, now time.Time) error {
+	version := now.UTC().Format("20060102150405")
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		file.Close()
+		fmt.Println(path)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func TestPlan(t *testing.T) {
+	files := fstest.MapFS{}
+	for _, name := range []string{"1_create_users", "2_create_orders", "3_create_invoices"} {
+		files[name+".up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+		files[name+".down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	}
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		current int
+		cmd     command
+		want    []planStep
+	}{
+		{"should apply all pending migrations", 1, command{name: "up"}, []planStep{{2, true}, {3, true}}},
+		{"should apply the next N migrations", -1, command{name: "up", steps: 2}, []planStep{{1, true}, {2, true}}},
+		{"should roll back the last N migrations newest first", 3, command{name: "down", steps: 2}, []planStep{{3, false}, {2, false}}},
+		{"should keep the target of goto applied when going down", 3, command{name: "goto", version: 1}, []planStep{{3, false}, {2, false}}},
+		{"should apply up to the target of goto", -1, command{name: "goto", version: 2}, []planStep{{1, true}, {2, true}}},
+		{"should have nothing to do at the latest version", 3, command{name: "up"}, []planStep{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plan(src, tt.current, tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("should reject a goto to an unknown version", func(t *testing.T) {
+		if _, err := plan(src, 1, command{name: "goto", version: 4}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"up"}, false},
+		{[]string{"down", "2"}, false},
+		{[]string{"force", "-1"}, false},
+		{[]string{"create", "add-tables-to orders"}, false},
+		{[]string{}, true},
+		{[]string{"up", "0"}, true},
+		{[]string{"goto"}, true},
+		{[]string{"goto", "-1"}, true},
+		{[]string{"status", "now"}, true},
+		{[]string{"create", "../outside"}, true},
+		{[]string{"drop"}, true},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseCommand(tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("parseCommand(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
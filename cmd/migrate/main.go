@@ -1,87 +1,109 @@
 package main
 
 import (
-	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"go-restaurant-management/config"
 	"go-restaurant-management/internal/app"
 	"log"
 	"os"
 	"strconv"
+	"time"
 
-	mysqlCfg "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+const usage = `Usage: migrate [flags] <command> [arguments]
+
+Commands:
+  up [N]        apply all or the next N pending migrations
+  down [N]      roll back all or the last N applied migrations
+  goto V        migrate up or down to version V
+  version       print the current version
+  status        list the migrations and whether they are applied
+  force V       set the version without running migrations, -1 for none
+  create NAME   add empty up and down migrations to the migrations directory
+
+Flags:
+`
+
+const (
+	exitError = 1
+	exitUsage = 2
 )
 
 func main() {
-	// Connect to MySQL server without specifying a database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/", config.Envs.DB_USER, config.Envs.DB_PASSWORD, config.Envs.DB_ADDRESS)
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		log.Fatal("Migration: " + err.Error())
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the SQL that up, down and goto would run, without running it")
+	dir := flags.String("dir", "internal/app/migrations", "directory create adds migrations to")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
 	}
-	defer db.Close()
 
-	// Create the database if it doesn't exist
-	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", config.Envs.DB_NAME))
+	args, err := parseArgs(flags, os.Args[1:])
 	if err != nil {
-		log.Fatal("Migration: " + err.Error())
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(exitUsage)
 	}
 
-	// Now, connect to the database
-	dbWithDb, err := app.NewMySqlStorage(mysqlCfg.Config{
-		User:                 config.Envs.DB_USER,
-		Passwd:               config.Envs.DB_PASSWORD,
-		Addr:                 config.Envs.DB_ADDRESS,
-		DBName:               config.Envs.DB_NAME,
-		Net:                  "tcp",
-		AllowNativePasswords: true,
-		ParseTime:            true,
-	})
-
+	cmd, err := parseCommand(args)
 	if err != nil {
-		log.Fatal("Migration: " + err.Error())
+		fmt.Fprintln(os.Stderr, "Migration: "+err.Error())
+		flags.Usage()
+		os.Exit(exitUsage)
 	}
 
-	driver, err := mysql.WithInstance(dbWithDb, &mysql.Config{})
+	if cmd.name == "create" {
+		if err := createMigration(*dir, cmd.migrationName, time.Now()); err != nil {
+			log.Fatal("Migration: " + err.Error())
+		}
+		return
+	}
+
+	db, err := app.NewMySqlDatabase()
 	if err != nil {
 		log.Fatal("Migration: " + err.Error())
 	}
+	defer db.Close()
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://cmd/migrate/migrations",
-		"mysql",
-		driver,
-	)
+	m, err := app.NewMigrate(db)
 	if err != nil {
 		log.Fatal("Migration: " + err.Error())
 	}
+	defer m.Close()
 
-	cmd := os.Args[(len(os.Args) - 1)]
-	if cmd == "up" {
-		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal("Migration: " + err.Error())
-		}
-	}
-	if cmd == "down" {
-		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal("Migration: " + err.Error())
+	if err := run(m, cmd, *dryRun); err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			log.Println("Migration: No change")
+			return
 		}
+		log.Println("Migration: " + err.Error())
+		os.Exit(exitError)
 	}
-	if cmd == "force" {
-		if len(os.Args) < 3 {
-			log.Fatal("Migration: Missing version argument for force command")
+}
+
+// parseArgs accepts flags before and after the arguments, which it returns.
+// Negative numbers, like in "force -1", are arguments.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if _, err := strconv.Atoi(args[0]); err == nil {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
 		}
-		version := os.Args[2]
-		v, err := strconv.Atoi(version)
-		if err != nil {
-			log.Fatal("Migration: Invalid version number")
+
+		if err := flags.Parse(args); err != nil {
+			return nil, err
 		}
-		if err := m.Force(v); err != nil {
-			log.Fatal("Migration: " + err.Error())
+		args = flags.Args()
+		if len(args) > 0 {
+			positional = append(positional, args[0])
+			args = args[1:]
 		}
 	}
-	log.Println("Migration: Successfully executed")
+	return positional, nil
 }
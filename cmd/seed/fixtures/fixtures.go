@@ -0,0 +1,8 @@
+// Package fixtures embeds the demo data loaded by the seed command. Files are
+// applied in name order and declare the format version they are written in.
+package fixtures
+
+import "embed"
+
+//go:embed *.json
+var FS embed.FS
@@ -0,0 +1,64 @@
+// The seed command loads the demo restaurant from the fixtures embedded in
+// cmd/seed/fixtures: staff and customers, menus with their foods, tables and
+// a week of orders with their invoices.
+package main
+
+import (
+	"errors"
+	"flag"
+	"go-restaurant-management/cmd/seed/fixtures"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/app"
+	"log"
+	"os"
+	"time"
+)
+
+const exitUsage = 2
+
+func main() {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	password := flags.String("password", config.Envs.SEED_PASSWORD, "password of the users that don't exist yet (required, defaults to SEED_PASSWORD)")
+	migrate := flags.Bool("migrate", true, "apply the pending migrations first")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(exitUsage)
+	}
+	if flags.NArg() > 0 {
+		log.Printf("Seed: unexpected arguments %q", flags.Args())
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+	if *password == "" {
+		log.Println("Seed: a password is required, pass -password or set SEED_PASSWORD")
+		flags.Usage()
+		os.Exit(exitUsage)
+	}
+
+	// Fixtures are checked before touching the database
+	loaded, err := loadFixtures(fixtures.FS)
+	if err != nil {
+		log.Fatal("Seed: " + err.Error())
+	}
+
+	db, err := app.NewMySqlDatabase()
+	if err != nil {
+		log.Fatal("Seed: " + err.Error())
+	}
+	defer db.Close()
+
+	lockTimeout := time.Duration(config.Envs.DB_MIGRATION_LOCK_TIMEOUT) * time.Second
+	if err := app.PrepareSchema(db, *migrate, lockTimeout); err != nil {
+		log.Fatal("Seed: " + err.Error())
+	}
+
+	now := time.Now()
+	for _, f := range loaded {
+		if err := seed(db, f, *password, now); err != nil {
+			log.Fatalf("Seed: %s: %v", f.name, err)
+		}
+	}
+	log.Println("Seed: Successfully executed")
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+	"io/fs"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// fixtureVersion is the format of the fixture files this seeder reads. Files
+// in another format are refused instead of being half applied.
+const fixtureVersion = 2
+
+// fixture is the content of a fixture file. Sections this seeder doesn't know
+// are refused, so data for tables without a migration can't be lost silently.
+type fixture struct {
+	Version int            `json:"version"`
+	Users   []userFixture  `json:"users"`
+	Menus   []menuFixture  `json:"menus"`
+	Tables  []tableFixture `json:"tables"`
+	Orders  []orderFixture `json:"orders"`
+}
+
+type userFixture struct {
+	First_name string `json:"first_name"`
+	Last_name  string `json:"last_name"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	Role       string `json:"role"`
+	// Pin is only allowed for staff without two-factor authentication, who log
+	// in on POS terminals with it
+	Pin string `json:"pin"`
+}
+
+// Menus, foods, tables and invoices are checked with the rules of their
+// types, so the demo data is what the API would accept.
+type menuFixture struct {
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Foods    []foodFixture `json:"foods"`
+}
+
+type foodFixture struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Image       string  `json:"image"`
+}
+
+type tableFixture struct {
+	Table_number     uint64 `json:"table_number"`
+	Number_of_guests uint64 `json:"number_of_guests"`
+}
+
+// orderFixture refers to its table by number, its waiter by email and its
+// foods by name. It is placed days_ago days before the first seed, at time.
+type orderFixture struct {
+	Reference string             `json:"reference"`
+	Table     uint64             `json:"table"`
+	Waiter    string             `json:"waiter"`
+	Days_ago  int                `json:"days_ago"`
+	Time      string             `json:"time"`
+	Items     []orderItemFixture `json:"items"`
+	// Invoice is left out for orders that are still open
+	Invoice *invoiceFixture `json:"invoice"`
+}
+
+type orderItemFixture struct {
+	Food     string `json:"food"`
+	Quantity int    `json:"quantity"`
+}
+
+type invoiceFixture struct {
+	Payment_method string `json:"payment_method"`
+	Payment_status string `json:"payment_status"`
+	Tax_id         string `json:"tax_id"`
+}
+
+type namedFixture struct {
+	name string
+	fixture
+}
+
+var pinPattern = regexp.MustCompile(`^[0-9]{4,6}$`)
+
+// loadFixtures reads and validates all the fixture files, in name order.
+func loadFixtures(fsys fs.FS) ([]namedFixture, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+
+	fixtures := make([]namedFixture, 0, len(names))
+	emails := map[string]string{}
+	staff := map[string]bool{}
+	menus := map[string]string{}
+	foods := map[string]string{}
+	tables := map[uint64]string{}
+	references := map[string]string{}
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var f fixture
+		decoder := json.NewDecoder(strings.NewReader(string(content)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&f); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if f.Version != fixtureVersion {
+			return nil, fmt.Errorf("%s: format version %d is not supported, expected %d", name, f.Version, fixtureVersion)
+		}
+
+		for _, u := range f.Users {
+			if err := validateUser(u); err != nil {
+				return nil, fmt.Errorf("%s: user %s: %w", name, u.Email, err)
+			}
+			email := strings.ToLower(u.Email)
+			if previous, ok := emails[email]; ok {
+				return nil, fmt.Errorf("%s: user %s is already in %s", name, u.Email, previous)
+			}
+			emails[email] = name
+			staff[email] = (user.User{Role: u.Role}).IsStaff()
+		}
+
+		for _, m := range f.Menus {
+			if err := utils.ValidateStruct(types.Menu{Name: m.Name, Category: m.Category}); err != nil {
+				return nil, fmt.Errorf("%s: menu %s: %w", name, m.Name, err)
+			}
+			if previous, ok := menus[m.Name]; ok {
+				return nil, fmt.Errorf("%s: menu %s is already in %s", name, m.Name, previous)
+			}
+			menus[m.Name] = name
+
+			for _, food := range m.Foods {
+				if err := validateFood(food); err != nil {
+					return nil, fmt.Errorf("%s: food %s: %w", name, food.Name, err)
+				}
+				if previous, ok := foods[food.Name]; ok {
+					return nil, fmt.Errorf("%s: food %s is already in %s", name, food.Name, previous)
+				}
+				foods[food.Name] = name
+			}
+		}
+
+		for _, table := range f.Tables {
+			if err := utils.ValidateStruct(types.Table{Table_number: table.Table_number, Number_of_guests: table.Number_of_guests}); err != nil {
+				return nil, fmt.Errorf("%s: table %d: %w", name, table.Table_number, err)
+			}
+			if previous, ok := tables[table.Table_number]; ok {
+				return nil, fmt.Errorf("%s: table %d is already in %s", name, table.Table_number, previous)
+			}
+			tables[table.Table_number] = name
+		}
+
+		fixtures = append(fixtures, namedFixture{name: name, fixture: f})
+	}
+
+	// Orders can refer to anything in the fixtures, as long as it is seeded
+	// before them
+	for _, f := range fixtures {
+		for _, o := range f.Orders {
+			if err := validateOrder(o); err != nil {
+				return nil, fmt.Errorf("%s: order %s: %w", f.name, o.Reference, err)
+			}
+			if previous, ok := references[o.Reference]; ok {
+				return nil, fmt.Errorf("%s: order %s is already in %s", f.name, o.Reference, previous)
+			}
+			references[o.Reference] = f.name
+
+			if previous, ok := tables[o.Table]; !ok || previous > f.name {
+				return nil, fmt.Errorf("%s: order %s: table %d isn't in this or an earlier fixture", f.name, o.Reference, o.Table)
+			}
+			waiter := strings.ToLower(o.Waiter)
+			if previous, ok := emails[waiter]; !ok || previous > f.name || !staff[waiter] {
+				return nil, fmt.Errorf("%s: order %s: waiter %s isn't a staff user of this or an earlier fixture", f.name, o.Reference, o.Waiter)
+			}
+			for _, item := range o.Items {
+				if previous, ok := foods[item.Food]; !ok || previous > f.name {
+					return nil, fmt.Errorf("%s: order %s: food %s isn't in this or an earlier fixture", f.name, o.Reference, item.Food)
+				}
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+func validateUser(u userFixture) error {
+	if u.First_name == "" || u.Last_name == "" || u.Phone == "" || !strings.Contains(u.Email, "@") {
+		return fmt.Errorf("first_name, last_name, phone and a valid email are required")
+	}
+	if !user.IsValidRole(u.Role) {
+		return fmt.Errorf("unknown role %q", u.Role)
+	}
+	if u.Pin != "" && !(user.User{Role: u.Role}).IsStaff() {
+		return fmt.Errorf("only staff can have a PIN")
+	}
+	if u.Pin != "" && (user.User{Role: u.Role}).UsesTwoFactor() {
+		return fmt.Errorf("the %s role uses two-factor authentication and can't have a PIN", u.Role)
+	}
+	if u.Pin != "" && !pinPattern.MatchString(u.Pin) {
+		return fmt.Errorf("the PIN must have 4 to 6 digits")
+	}
+	return nil
+}
+
+func validateFood(f foodFixture) error {
+	// The menu is only known once it is seeded
+	food := types.Food{Name: f.Name, Description: f.Description, Price: f.Price, Image: f.Image}
+	if err := utils.Validate.StructExcept(food, "Menu_id"); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return utils.FormatValidationError(validationErrors)
+		}
+		return err
+	}
+	// Prices are stored with 2 decimals
+	if f.Price <= 0 || math.Abs(f.Price*100-math.Round(f.Price*100)) > 1e-6 {
+		return fmt.Errorf("the price must be positive, with up to 2 decimals")
+	}
+	return nil
+}
+
+func validateOrder(o orderFixture) error {
+	if o.Reference == "" {
+		return fmt.Errorf("orders must have a reference")
+	}
+	if o.Days_ago < 0 {
+		return fmt.Errorf("days_ago can't be negative")
+	}
+	if _, err := time.Parse("15:04", o.Time); err != nil {
+		return fmt.Errorf("the time must be formatted as 15:04")
+	}
+	if len(o.Items) == 0 {
+		return fmt.Errorf("orders must have items")
+	}
+	foods := map[string]bool{}
+	for _, item := range o.Items {
+		if item.Quantity < 1 {
+			return fmt.Errorf("the quantity of %s must be positive", item.Food)
+		}
+		if foods[item.Food] {
+			return fmt.Errorf("%s is listed twice", item.Food)
+		}
+		foods[item.Food] = true
+	}
+	if o.Invoice != nil {
+		invoice := types.Invoice{Payment_method: o.Invoice.Payment_method, Payment_status: o.Invoice.Payment_status, Tax_id: o.Invoice.Tax_id}
+		if err := utils.ValidateStruct(invoice); err != nil {
+			return fmt.Errorf("invoice: %w", err)
+		}
+	}
+	return nil
+}
+
+// seed applies a fixture in a transaction. Rows are matched by their natural
+// key, like the email of users, and running it again changes nothing:
+//   - users get their names, phone and role updated but keep their password
+//     and PIN
+//   - menus, foods and tables are updated
+//   - orders, with their items and invoice, are only created
+func seed(db *sql.DB, f namedFixture, password string, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(f.Users) > 0 {
+		if err := seedUsers(tx, f, password, now); err != nil {
+			return err
+		}
+	}
+	if len(f.Menus) > 0 {
+		if err := seedMenus(tx, f); err != nil {
+			return err
+		}
+	}
+	if len(f.Tables) > 0 {
+		if err := seedTables(tx, f); err != nil {
+			return err
+		}
+	}
+	if len(f.Orders) > 0 {
+		if err := seedOrders(tx, f, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// counts tells what the upserts of a section did.
+type counts struct {
+	created, updated, unchanged int
+}
+
+func (c *counts) add(res sql.Result) error {
+	// 1 for a new row, 2 for a changed one and 0 when nothing changed
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	switch affected {
+	case 0:
+		c.unchanged++
+	case 1:
+		c.created++
+	default:
+		c.updated++
+	}
+	return nil
+}
+
+func (c counts) log(fixture string, section string) {
+	log.Printf("Seed: %s: %d %s created, %d updated, %d unchanged", fixture, c.created, section, c.updated, c.unchanged)
+}
+
+func seedUsers(tx *sql.Tx, f namedFixture, password string, now time.Time) error {
+	passwordHash, err := user.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// version is assigned first, so it compares with the previous values
+	query := `INSERT INTO users (first_name, last_name, email, password, pin_hash, phone, role, verified_at) VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			version = IF(first_name = VALUES(first_name) AND last_name = VALUES(last_name) AND phone = VALUES(phone) AND role = VALUES(role), version, version + 1),
+			first_name = VALUES(first_name),
+			last_name = VALUES(last_name),
+			phone = VALUES(phone),
+			role = VALUES(role),
+			verified_at = COALESCE(verified_at, VALUES(verified_at))`
+
+	var c counts
+	for _, u := range f.Users {
+		pinHash := ""
+		if u.Pin != "" {
+			if pinHash, err = user.HashPassword(u.Pin); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(query, u.First_name, u.Last_name, strings.ToLower(u.Email), passwordHash, pinHash, u.Phone, u.Role, now)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Email, err)
+		}
+		if err := c.add(res); err != nil {
+			return err
+		}
+	}
+
+	c.log(f.name, "users")
+	return nil
+}
+
+func seedMenus(tx *sql.Tx, f namedFixture) error {
+	menuQuery := `INSERT INTO menus (name, category) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE category = VALUES(category)`
+	foodQuery := `INSERT INTO foods (menu_id, name, description, price, image) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE menu_id = VALUES(menu_id), description = VALUES(description), price = VALUES(price), image = VALUES(image)`
+
+	var menus, foods counts
+	for _, m := range f.Menus {
+		res, err := tx.Exec(menuQuery, m.Name, m.Category)
+		if err != nil {
+			return fmt.Errorf("menu %s: %w", m.Name, err)
+		}
+		if err := menus.add(res); err != nil {
+			return err
+		}
+
+		var menuID int
+		if err := tx.QueryRow("SELECT id FROM menus WHERE name = ?", m.Name).Scan(&menuID); err != nil {
+			return fmt.Errorf("menu %s: %w", m.Name, err)
+		}
+
+		for _, food := range m.Foods {
+			res, err := tx.Exec(foodQuery, menuID, food.Name, food.Description, price(food.Price), food.Image)
+			if err != nil {
+				return fmt.Errorf("food %s: %w", food.Name, err)
+			}
+			if err := foods.add(res); err != nil {
+				return err
+			}
+		}
+	}
+
+	menus.log(f.name, "menus")
+	foods.log(f.name, "foods")
+	return nil
+}
+
+func seedTables(tx *sql.Tx, f namedFixture) error {
+	query := `INSERT INTO dining_tables (table_number, number_of_guests) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE number_of_guests = VALUES(number_of_guests)`
+
+	var c counts
+	for _, table := range f.Tables {
+		res, err := tx.Exec(query, table.Table_number, table.Number_of_guests)
+		if err != nil {
+			return fmt.Errorf("table %d: %w", table.Table_number, err)
+		}
+		if err := c.add(res); err != nil {
+			return err
+		}
+	}
+
+	c.log(f.name, "tables")
+	return nil
+}
+
+// seedOrders creates the orders that don't exist yet. Their dates are relative
+// to the first seed, so the demo restaurant starts with a week of history.
+func seedOrders(tx *sql.Tx, f namedFixture, now time.Time) error {
+	var c counts
+	for _, o := range f.Orders {
+		var tableID, waiterID int
+		if err := tx.QueryRow("SELECT id FROM dining_tables WHERE table_number = ?", o.Table).Scan(&tableID); err != nil {
+			return fmt.Errorf("order %s: table %d: %w", o.Reference, o.Table, err)
+		}
+		if err := tx.QueryRow("SELECT id FROM users WHERE email = ?", strings.ToLower(o.Waiter)).Scan(&waiterID); err != nil {
+			return fmt.Errorf("order %s: waiter %s: %w", o.Reference, o.Waiter, err)
+		}
+
+		orderDate := orderTime(now, o.Days_ago, o.Time)
+		res, err := tx.Exec(`INSERT INTO orders (reference, order_date, table_id, waiter_id) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = id`, o.Reference, orderDate, tableID, waiterID)
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.Reference, err)
+		}
+		existing := c.unchanged
+		if err := c.add(res); err != nil {
+			return err
+		}
+		if c.unchanged > existing {
+			continue
+		}
+
+		orderID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// Items keep the price the food had when it was ordered
+		for _, item := range o.Items {
+			var foodID int
+			var unitPrice float64
+			if err := tx.QueryRow("SELECT id, price FROM foods WHERE name = ?", item.Food).Scan(&foodID, &unitPrice); err != nil {
+				return fmt.Errorf("order %s: food %s: %w", o.Reference, item.Food, err)
+			}
+			if _, err := tx.Exec("INSERT INTO order_items (order_id, food_id, quantity, unit_price) VALUES (?, ?, ?, ?)",
+				orderID, foodID, item.Quantity, price(unitPrice)); err != nil {
+				return fmt.Errorf("order %s: food %s: %w", o.Reference, item.Food, err)
+			}
+		}
+
+		// Invoices are due when the order is placed
+		if o.Invoice != nil {
+			if _, err := tx.Exec("INSERT INTO invoices (order_id, payment_method, payment_status, payment_due_date, tax_id) VALUES (?, ?, ?, ?, ?)",
+				orderID, o.Invoice.Payment_method, o.Invoice.Payment_status, orderDate, o.Invoice.Tax_id); err != nil {
+				return fmt.Errorf("order %s: invoice: %w", o.Reference, err)
+			}
+		}
+	}
+
+	c.log(f.name, "orders")
+	return nil
+}
+
+// orderTime is the time of day hhmm, daysAgo days before now. Orders of today
+// later than now are placed now.
+func orderTime(now time.Time, daysAgo int, hhmm string) time.Time {
+	clock, _ := time.Parse("15:04", hhmm)
+	day := now.AddDate(0, 0, -daysAgo)
+	t := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if t.After(now) {
+		return now
+	}
+	return t
+}
+
+// price formats an amount for a DECIMAL(10, 2) column.
+func price(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"go-restaurant-management/cmd/seed/fixtures"
+	"go-restaurant-management/internal/domain/user"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	t.Run("should load the embedded fixtures with a user of each role", func(t *testing.T) {
+		loaded, err := loadFixtures(fixtures.FS)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		roles := map[string]bool{}
+		for _, f := range loaded {
+			for _, u := range f.Users {
+				roles[u.Role] = true
+			}
+		}
+		for _, role := range user.Roles {
+			if !roles[role] {
+				t.Errorf("expected a user with role %s", role)
+			}
+		}
+	})
+
+	t.Run("should load a week of orders", func(t *testing.T) {
+		loaded, err := loadFixtures(fixtures.FS)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		days := map[int]bool{}
+		for _, f := range loaded {
+			for _, o := range f.Orders {
+				days[o.Days_ago] = true
+			}
+		}
+		for day := 0; day < 7; day++ {
+			if !days[day] {
+				t.Errorf("expected orders %d days ago", day)
+			}
+		}
+	})
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"should refuse another format version", `{"version": 1, "users": []}`},
+		{"should refuse sections it can't seed", `{"version": 2, "reservations": [{"table": 1}]}`},
+		{"should refuse unknown roles", `{"version": 2, "users": [{"first_name": "Ana", "last_name": "Souza", "email": "ana@example.com", "phone": "+5511987651001", "role": "chef"}]}`},
+		{"should refuse PINs for customers", `{"version": 2, "users": [{"first_name": "Ana", "last_name": "Souza", "email": "ana@example.com", "phone": "+5511987651001", "role": "customer", "pin": "4826"}]}`},
+		{"should refuse PINs for roles with two-factor", `{"version": 2, "users": [{"first_name": "Rafael", "last_name": "Moreira", "email": "manager@example.com", "phone": "+5511987650002", "role": "manager", "pin": "4826"}]}`},
+	}
+
+	// An order of a waiter, for table 1, with a dessert
+	order := func(fields string) string {
+		return `{"version": 2,
+			"users": [{"first_name": "Ana", "last_name": "Souza", "email": "ana@example.com", "phone": "+5511987651001", "role": "waiter"}],
+			"menus": [{"name": "Desserts", "category": "Food", "foods": [{"name": "Pudim", "description": "Condensed milk flan", "price": 19.00, "image": "foods/pudim.jpg"}]}],
+			"tables": [{"table_number": 1, "number_of_guests": 2}],
+			"orders": [{"reference": "DEMO-1", "waiter": "ana@example.com", "days_ago": 1, "time": "12:30", ` + fields + `}]}`
+	}
+	tests = append(tests, []struct {
+		name    string
+		content string
+	}{
+		{"should refuse prices with more than 2 decimals", `{"version": 2, "menus": [{"name": "Desserts", "category": "Food", "foods": [{"name": "Pudim", "description": "Condensed milk flan", "price": 19.005, "image": "foods/pudim.jpg"}]}]}`},
+		{"should refuse foods without an image", `{"version": 2, "menus": [{"name": "Desserts", "category": "Food", "foods": [{"name": "Pudim", "description": "Condensed milk flan", "price": 19.00}]}]}`},
+		{"should refuse orders of unknown tables", order(`"table": 2, "items": [{"food": "Pudim", "quantity": 1}]`)},
+		{"should refuse orders of unknown foods", order(`"table": 1, "items": [{"food": "Brigadeiro", "quantity": 1}]`)},
+		{"should refuse unknown payment methods", order(`"table": 1, "items": [{"food": "Pudim", "quantity": 1}], "invoice": {"payment_method": "pix", "payment_status": "PAID"}`)},
+		{"should refuse invoices without a payment status", order(`"table": 1, "items": [{"food": "Pudim", "quantity": 1}], "invoice": {"payment_method": "PIX"}`)},
+	}...)
+
+	t.Run("should accept orders referring to the fixtures", func(t *testing.T) {
+		files := fstest.MapFS{"001_test.json": &fstest.MapFile{Data: []byte(order(`"table": 1, "items": [{"food": "Pudim", "quantity": 2}], "invoice": {"payment_method": "PIX", "payment_status": "PAID"}`))}}
+		if _, err := loadFixtures(files); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fstest.MapFS{"001_test.json": &fstest.MapFile{Data: []byte(tt.content)}}
+			if _, err := loadFixtures(files); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
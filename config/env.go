@@ -10,14 +10,89 @@ import (
 type Config struct {
 	PUBLIC_HOST string
 	PORT        string
+	APP_URL     string // Base URL used in links sent to users
+
+	SHUTDOWN_TIMEOUT int64 // In seconds, how long requests in flight get to finish on shutdown
 
 	DB_ADDRESS  string
 	DB_USER     string
 	DB_PASSWORD string
 	DB_NAME     string
 
-	JWT_SECRET string
-	JWT_EXPIRE int64 // In seconds
+	DB_AUTO_MIGRATE           bool  // Apply pending migrations when the API starts
+	DB_MIGRATION_LOCK_TIMEOUT int64 // In seconds, how long to wait for another instance migrating
+
+	SEED_PASSWORD string // Password of the users created by the seed command, it has no default
+
+	JWT_SECRET         string
+	JWT_EXPIRE         int64 // In seconds
+	JWT_REFRESH_EXPIRE int64 // In seconds
+
+	BCRYPT_COST int64
+
+	PASSWORD_MIN_LENGTH     int64
+	PASSWORD_REQUIRE_UPPER  bool
+	PASSWORD_REQUIRE_LOWER  bool
+	PASSWORD_REQUIRE_DIGIT  bool
+	PASSWORD_REQUIRE_SYMBOL bool
+
+	TRUSTED_PROXIES string // IPs and CIDRs, separated by ",", whose X-Forwarded-For / X-Real-IP are honored
+
+	LOCKOUT_STORE                string // "mysql" or "memory"
+	LOCKOUT_MAX_ACCOUNT_FAILURES int64
+	LOCKOUT_MAX_IP_FAILURES      int64
+	LOCKOUT_DURATION             int64 // In seconds
+
+	RATE_LIMIT_ENABLED bool
+	RATE_LIMIT_DEFAULT string // "<requests>/<period>", e.g. "120/1m"
+	RATE_LIMIT_ROUTES  string // "<path prefix>=<requests>/<period>" separated by ";"
+
+	IDEMPOTENCY_ENABLED        bool
+	IDEMPOTENCY_ROUTES         string // Path prefixes separated by ";" whose responses are replayed
+	IDEMPOTENCY_TTL            int64  // In seconds, how long responses are replayed
+	IDEMPOTENCY_LOCK_TIMEOUT   int64  // In seconds, after which an unfinished request can be retried
+	IDEMPOTENCY_MAX_BODY_BYTES int64
+
+	NOTIFIER_TRANSPORT string // "console", "file" or "email"
+	NOTIFIER_DIR       string
+
+	MAIL_TRANSPORT   string // "smtp" or "file"
+	MAIL_FROM        string
+	MAIL_DIR         string // Maildir used by the file transport
+	MAIL_QUEUE_SIZE  int64
+	MAIL_WORKERS     int64
+	MAIL_MAX_RETRIES int64
+
+	SMTP_HOST         string
+	SMTP_PORT         string
+	SMTP_USERNAME     string
+	SMTP_PASSWORD     string
+	SMTP_IMPLICIT_TLS bool // Connect with TLS (port 465) instead of upgrading with STARTTLS
+
+	STORAGE_DRIVER   string // "local"
+	STORAGE_DIR      string
+	STORAGE_BASE_URL string // URL path or address the stored files are served from
+
+	JSON_MAX_BODY_BYTES          int64
+	JSON_DISALLOW_UNKNOWN_FIELDS bool // Reject request bodies with fields the endpoint doesn't know
+
+	AVATAR_MAX_BYTES int64
+	AVATAR_SIZE      int64 // Width and height of stored avatars, in pixels
+
+	PASSWORD_RESET_EXPIRE int64 // In seconds
+
+	TWO_FACTOR_ISSUER           string // Account issuer shown in authenticator apps
+	TWO_FACTOR_REQUIRED_ROLES   string // Comma separated roles that must enroll
+	TWO_FACTOR_CHALLENGE_EXPIRE int64  // In seconds
+
+	POS_TOKEN_EXPIRE          int64 // In seconds, tokens issued by PIN logins
+	PIN_MAX_FAILURES          int64
+	PIN_MAX_TERMINAL_FAILURES int64
+	PIN_LOCKOUT_DURATION      int64 // In seconds
+
+	EMAIL_VERIFICATION_EXPIRE          int64 // In seconds
+	EMAIL_VERIFICATION_RESEND_COOLDOWN int64 // In seconds
+	REQUIRE_VERIFIED_EMAIL_FOR_ORDERS  bool
 }
 
 var Envs = initConfig()
@@ -28,12 +103,89 @@ func initConfig() Config {
 	return Config{
 		PUBLIC_HOST: getEnv("PUBLIC_HOST", "localhost"),
 		PORT:        getEnv("PORT", "8080"),
+		APP_URL:     getEnv("APP_URL", "http://localhost:8080"),
+
+		SHUTDOWN_TIMEOUT: getEnvAsInt("SHUTDOWN_TIMEOUT", 30),
+
 		DB_ADDRESS:  getEnv("DB_ADDRESS", "localhost"),
 		DB_USER:     getEnv("DB_USER", "root"),
 		DB_PASSWORD: getEnv("DB_PASSWORD", ""),
 		DB_NAME:     getEnv("DB_NAME", "ecommerce"),
-		JWT_SECRET:  getEnv("JWT_SECRET", "secret"),
-		JWT_EXPIRE:  getEnvAsInt("JWT_EXPIRE", 1*60*60),
+
+		DB_AUTO_MIGRATE:           getEnvAsBool("DB_AUTO_MIGRATE", false),
+		DB_MIGRATION_LOCK_TIMEOUT: getEnvAsInt("DB_MIGRATION_LOCK_TIMEOUT", 60),
+
+		SEED_PASSWORD: getEnv("SEED_PASSWORD", ""),
+
+		JWT_SECRET:         getEnv("JWT_SECRET", "secret"),
+		JWT_EXPIRE:         getEnvAsInt("JWT_EXPIRE", 1*60*60),
+		JWT_REFRESH_EXPIRE: getEnvAsInt("JWT_REFRESH_EXPIRE", 7*24*60*60),
+
+		BCRYPT_COST: getEnvAsInt("BCRYPT_COST", 10),
+
+		PASSWORD_MIN_LENGTH:     getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PASSWORD_REQUIRE_UPPER:  getEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+		PASSWORD_REQUIRE_LOWER:  getEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+		PASSWORD_REQUIRE_DIGIT:  getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+		PASSWORD_REQUIRE_SYMBOL: getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+
+		TRUSTED_PROXIES: getEnv("TRUSTED_PROXIES", ""),
+
+		LOCKOUT_STORE:                getEnv("LOCKOUT_STORE", "mysql"),
+		LOCKOUT_MAX_ACCOUNT_FAILURES: getEnvAsInt("LOCKOUT_MAX_ACCOUNT_FAILURES", 5),
+		LOCKOUT_MAX_IP_FAILURES:      getEnvAsInt("LOCKOUT_MAX_IP_FAILURES", 20),
+		LOCKOUT_DURATION:             getEnvAsInt("LOCKOUT_DURATION", 15*60),
+
+		RATE_LIMIT_ENABLED: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		RATE_LIMIT_DEFAULT: getEnv("RATE_LIMIT_DEFAULT", "120/1m"),
+		RATE_LIMIT_ROUTES:  getEnv("RATE_LIMIT_ROUTES", "/api/auth/=20/1m"),
+
+		IDEMPOTENCY_ENABLED:        getEnvAsBool("IDEMPOTENCY_ENABLED", true),
+		IDEMPOTENCY_ROUTES:         getEnv("IDEMPOTENCY_ROUTES", "/api/orders;/api/payments"),
+		IDEMPOTENCY_TTL:            getEnvAsInt("IDEMPOTENCY_TTL", 24*60*60),
+		IDEMPOTENCY_LOCK_TIMEOUT:   getEnvAsInt("IDEMPOTENCY_LOCK_TIMEOUT", 60),
+		IDEMPOTENCY_MAX_BODY_BYTES: getEnvAsInt("IDEMPOTENCY_MAX_BODY_BYTES", 10<<20),
+
+		NOTIFIER_TRANSPORT: getEnv("NOTIFIER_TRANSPORT", "console"),
+		NOTIFIER_DIR:       getEnv("NOTIFIER_DIR", "tmp/notifications"),
+
+		MAIL_TRANSPORT:   getEnv("MAIL_TRANSPORT", "file"),
+		MAIL_FROM:        getEnv("MAIL_FROM", "Restaurant <no-reply@localhost>"),
+		MAIL_DIR:         getEnv("MAIL_DIR", "tmp/maildir"),
+		MAIL_QUEUE_SIZE:  getEnvAsInt("MAIL_QUEUE_SIZE", 100),
+		MAIL_WORKERS:     getEnvAsInt("MAIL_WORKERS", 2),
+		MAIL_MAX_RETRIES: getEnvAsInt("MAIL_MAX_RETRIES", 5),
+
+		SMTP_HOST:         getEnv("SMTP_HOST", "localhost"),
+		SMTP_PORT:         getEnv("SMTP_PORT", "587"),
+		SMTP_USERNAME:     getEnv("SMTP_USERNAME", ""),
+		SMTP_PASSWORD:     getEnv("SMTP_PASSWORD", ""),
+		SMTP_IMPLICIT_TLS: getEnvAsBool("SMTP_IMPLICIT_TLS", false),
+
+		STORAGE_DRIVER:   getEnv("STORAGE_DRIVER", "local"),
+		STORAGE_DIR:      getEnv("STORAGE_DIR", "uploads"),
+		STORAGE_BASE_URL: getEnv("STORAGE_BASE_URL", "/uploads"),
+
+		JSON_MAX_BODY_BYTES:          getEnvAsInt("JSON_MAX_BODY_BYTES", 1<<20),
+		JSON_DISALLOW_UNKNOWN_FIELDS: getEnvAsBool("JSON_DISALLOW_UNKNOWN_FIELDS", true),
+
+		AVATAR_MAX_BYTES: getEnvAsInt("AVATAR_MAX_BYTES", 5<<20),
+		AVATAR_SIZE:      getEnvAsInt("AVATAR_SIZE", 256),
+
+		PASSWORD_RESET_EXPIRE: getEnvAsInt("PASSWORD_RESET_EXPIRE", 30*60),
+
+		TWO_FACTOR_ISSUER:           getEnv("TWO_FACTOR_ISSUER", "Restaurant"),
+		TWO_FACTOR_REQUIRED_ROLES:   getEnv("TWO_FACTOR_REQUIRED_ROLES", "manager,cashier,admin"),
+		TWO_FACTOR_CHALLENGE_EXPIRE: getEnvAsInt("TWO_FACTOR_CHALLENGE_EXPIRE", 5*60),
+
+		POS_TOKEN_EXPIRE:          getEnvAsInt("POS_TOKEN_EXPIRE", 15*60),
+		PIN_MAX_FAILURES:          getEnvAsInt("PIN_MAX_FAILURES", 3),
+		PIN_MAX_TERMINAL_FAILURES: getEnvAsInt("PIN_MAX_TERMINAL_FAILURES", 10),
+		PIN_LOCKOUT_DURATION:      getEnvAsInt("PIN_LOCKOUT_DURATION", 5*60),
+
+		EMAIL_VERIFICATION_EXPIRE:          getEnvAsInt("EMAIL_VERIFICATION_EXPIRE", 48*60*60),
+		EMAIL_VERIFICATION_RESEND_COOLDOWN: getEnvAsInt("EMAIL_VERIFICATION_RESEND_COOLDOWN", 60),
+		REQUIRE_VERIFIED_EMAIL_FOR_ORDERS:  getEnvAsBool("REQUIRE_VERIFIED_EMAIL_FOR_ORDERS", true),
 	}
 }
 
@@ -55,3 +207,15 @@ func getEnvAsInt(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fallback
+		}
+
+		return b
+	}
+	return fallback
+}
@@ -1,11 +1,26 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"go-restaurant-management/config"
 	"go-restaurant-management/internal/app/handler"
+	"go-restaurant-management/internal/domain/apikey"
+	"go-restaurant-management/internal/domain/idempotency"
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/terminal"
 	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/middleware"
+	"go-restaurant-management/internal/shared/notifier"
+	"go-restaurant-management/internal/shared/storage"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 type ApiServer struct {
@@ -22,12 +37,102 @@ func NewApiServer(addr string, db *sql.DB) *ApiServer {
 }
 
 func (s *ApiServer) Run() error {
+	lockTimeout := time.Duration(config.Envs.DB_MIGRATION_LOCK_TIMEOUT) * time.Second
+	if err := PrepareSchema(s.db, config.Envs.DB_AUTO_MIGRATE, lockTimeout); err != nil {
+		return err
+	}
+
+	appNotifier, err := notifier.NewNotifier()
+	if err != nil {
+		return err
+	}
+
+	fileStorage, fileHandler, err := storage.NewStorage()
+	if err != nil {
+		return err
+	}
+
 	// User
 	userRepository := user.NewUserRepository(s.db)
-	userService := user.NewUserService(userRepository)
+	userTokenRepository := user.NewUserTokenRepository(s.db)
+	recoveryCodeRepository := user.NewRecoveryCodeRepository(s.db)
+	sessionRepository := user.NewSessionRepository(s.db)
+	dataRequestRepository := user.NewDataRequestRepository(s.db)
+	userService := user.NewUserService(userRepository, userTokenRepository, recoveryCodeRepository, sessionRepository, dataRequestRepository, appNotifier, fileStorage)
+
+	// Lockout
+	lockoutRepository := lockout.NewLockoutRepository(s.db)
+	if config.Envs.LOCKOUT_STORE == "memory" {
+		lockoutRepository = lockout.NewMemoryLockoutRepository()
+	}
+	lockoutService := lockout.NewLockoutService(lockoutRepository, lockout.DefaultPolicy())
+	pinLockoutService := lockout.NewLockoutService(lockoutRepository, lockout.PinPolicy())
 
-	http.HandleFunc("/api/auth/", handler.AuthHandler(userService))
+	// Terminal
+	terminalRepository := terminal.NewTerminalRepository(s.db)
+	terminalService := terminal.NewTerminalService(terminalRepository)
+
+	// API keys
+	apiKeyRepository := apikey.NewApiKeyRepository(s.db)
+	apiKeyService := apikey.NewApiKeyService(apiKeyRepository)
+	authenticator := auth.NewAuthenticator(userService, apiKeyService)
+
+	pinHandler := handler.PinHandler(userService, terminalService, pinLockoutService, authenticator)
+	http.HandleFunc("/api/auth/", handler.AuthHandler(userService, lockoutService, authenticator))
+	http.HandleFunc("/api/auth/pin", pinHandler)
+	http.HandleFunc("/api/auth/pin-login", pinHandler)
+	http.HandleFunc("/api/admin/", handler.AdminHandler(userService, lockoutService, terminalService, apiKeyService, authenticator))
+	http.HandleFunc("/api/users/", handler.UsersHandler(userService, lockoutService, authenticator))
+	if fileHandler != nil {
+		http.Handle(config.Envs.STORAGE_BASE_URL+"/", fileHandler)
+	}
+
+	router := http.HandlerFunc(http.DefaultServeMux.ServeHTTP)
+	if config.Envs.IDEMPOTENCY_ENABLED {
+		idempotent := middleware.NewIdempotency(
+			idempotency.NewIdempotencyRepository(s.db),
+			authenticator,
+			middleware.ParseIdempotencyRoutes(config.Envs.IDEMPOTENCY_ROUTES),
+			time.Duration(config.Envs.IDEMPOTENCY_TTL)*time.Second,
+			time.Duration(config.Envs.IDEMPOTENCY_LOCK_TIMEOUT)*time.Second,
+			config.Envs.IDEMPOTENCY_MAX_BODY_BYTES,
+		)
+		idempotent.ResolveAPIKey = apiKeyService.Identify
+		router = idempotent.Handle(router)
+	}
+	if config.Envs.RATE_LIMIT_ENABLED {
+		defaultRule, rules, err := middleware.ParseRateLimitRules(config.Envs.RATE_LIMIT_DEFAULT, config.Envs.RATE_LIMIT_ROUTES)
+		if err != nil {
+			return err
+		}
+		limiter := middleware.NewRateLimiter(defaultRule, rules...)
+		limiter.ResolveAPIKey = apiKeyService.Identify
+		router = limiter.Limit(router)
+	}
+
+	server := &http.Server{Addr: s.addr, Handler: router}
+	shutdown := make(chan error, 1)
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		log.Println("Server is shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Envs.SHUTDOWN_TIMEOUT)*time.Second)
+		defer cancel()
+		shutdown <- server.Shutdown(ctx)
+	}()
 
 	log.Printf("Server has started, listening on %s", s.addr)
-	return http.ListenAndServe(s.addr, nil)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if err := <-shutdown; err != nil {
+		return err
+	}
+
+	// The requests and the work they left in the background are done, the
+	// mail they queued can be sent
+	userService.Close()
+	return appNotifier.Close()
 }
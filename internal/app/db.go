@@ -2,6 +2,8 @@ package app
 
 import (
 	"database/sql"
+	"fmt"
+	"go-restaurant-management/config"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -14,3 +16,35 @@ func NewMySqlStorage(cfg mysql.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// NewMySqlDatabase connects to the configured database, creating it first if
+// it doesn't exist. It is meant for the commands that prepare the database.
+func NewMySqlDatabase() (*sql.DB, error) {
+	// Connect to MySQL server without specifying a database
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/", config.Envs.DB_USER, config.Envs.DB_PASSWORD, config.Envs.DB_ADDRESS)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Create the database if it doesn't exist
+	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", config.Envs.DB_NAME))
+	if err != nil {
+		return nil, err
+	}
+
+	// Now, connect to the database
+	return NewMySqlStorage(mysql.Config{
+		User:                 config.Envs.DB_USER,
+		Passwd:               config.Envs.DB_PASSWORD,
+		Addr:                 config.Envs.DB_ADDRESS,
+		DBName:               config.Envs.DB_NAME,
+		Net:                  "tcp",
+		AllowNativePasswords: true,
+		ParseTime:            true,
+		// Some migrations run several statements, arguments are still sent
+		// apart from the queries
+		MultiStatements: true,
+	})
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"go-restaurant-management/internal/domain/apikey"
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/terminal"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/middleware"
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"net/http"
+)
+
+func AdminHandler(userService user.UserService, lockoutService lockout.LockoutService, terminalService terminal.TerminalService, apiKeyService apikey.ApiKeyService, authenticator *auth.Authenticator) http.HandlerFunc {
+	router := newRouter()
+
+	adminOnly := func(h middleware.HandlerFunc) http.HandlerFunc {
+		return utils.Compose(
+			middleware.ErrorHandlerFunc(h),
+			middleware.ErrorHandler,
+			authenticator.WithJwtAuth,
+			auth.WithRole(user.RoleAdmin),
+		)
+	}
+
+	// Managers run the staff, the rest stays with admins
+	managers := func(h middleware.HandlerFunc) http.HandlerFunc {
+		return utils.Compose(
+			middleware.ErrorHandlerFunc(h),
+			middleware.ErrorHandler,
+			authenticator.WithJwtAuth,
+			auth.WithRole(user.RoleAdmin, user.RoleManager),
+		)
+	}
+
+	router.HandleFunc("/api/admin/users", managers(func(w http.ResponseWriter, r *http.Request) error {
+		return listUsers(w, r, userService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/admin/users/{id}", managers(func(w http.ResponseWriter, r *http.Request) error {
+		return getUser(w, r, userService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/admin/users/{id}", managers(func(w http.ResponseWriter, r *http.Request) error {
+		return updateUser(w, r, userService)
+	})).Methods(http.MethodPatch)
+
+	router.HandleFunc("/api/admin/users/{id}/deactivate", managers(func(w http.ResponseWriter, r *http.Request) error {
+		return deactivateUser(w, r, userService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/users/{id}/reactivate", managers(func(w http.ResponseWriter, r *http.Request) error {
+		return reactivateUser(w, r, userService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/users/{id}/unlock", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return unlockUser(w, r, userService, lockoutService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/users/{id}/logout", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return logoutUser(w, r, userService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/terminals", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return listTerminals(w, r, terminalService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/admin/terminals", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return registerTerminal(w, r, terminalService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/terminals/{id}/revoke", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return revokeTerminal(w, r, terminalService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/api-keys", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return listApiKeys(w, r, apiKeyService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/admin/api-keys", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return createApiKey(w, r, apiKeyService)
+	})).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/admin/api-keys/{id}/revoke", adminOnly(func(w http.ResponseWriter, r *http.Request) error {
+		return revokeApiKey(w, r, apiKeyService)
+	})).Methods(http.MethodPost)
+
+	return router.ServeHTTP
+}
+
+func listUsers(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	log.Println("-> new request to list users")
+
+	query, err := utils.ParseListQuery(r, user.ListSpec)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := userService.ListUsers(query)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, utils.NewPage(r, query, users, &total, nil))
+	return nil
+}
+
+func getUser(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := userService.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJsonWithETag(w, r, http.StatusOK, user, utils.VersionETag(user.Version))
+	return nil
+}
+
+func updateUser(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to update user %d by user %d", id, claims.UserID)
+
+	current, err := userService.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckIfMatch(r, "user", utils.VersionETag(current.Version)); err != nil {
+		return err
+	}
+
+	patch := user.UserToPatch(current)
+	if err := utils.ParseAndApplyMergePatch(r, &patch); err != nil {
+		return err
+	}
+
+	updated, err := userService.UpdateUser(*claims, user.ApplyUserPatch(current, patch))
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJsonWithETag(w, r, http.StatusOK, updated, utils.VersionETag(updated.Version))
+	return nil
+}
+
+func deactivateUser(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to deactivate user %d by user %d", id, claims.UserID)
+
+	user, err := userService.DeactivateUser(*claims, id)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, user)
+	return nil
+}
+
+func reactivateUser(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to reactivate user %d by user %d", id, claims.UserID)
+
+	user, err := userService.ReactivateUser(*claims, id)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, user)
+	return nil
+}
+
+func unlockUser(w http.ResponseWriter, r *http.Request, userService user.UserService, lockoutService lockout.LockoutService) error {
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to unlock user %d", id)
+
+	user, err := userService.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := lockoutService.Unlock(user.Email); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "User unlocked successfully",
+	})
+	return nil
+}
+
+func logoutUser(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to revoke all sessions of user %d", id)
+
+	if _, err := userService.FindByID(id); err != nil {
+		return err
+	}
+
+	revoked, err := userService.LogoutAll(id)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message":          "User logged out of all devices",
+		"sessions_revoked": revoked,
+	})
+	return nil
+}
+
+func listTerminals(w http.ResponseWriter, r *http.Request, terminalService terminal.TerminalService) error {
+	terminals, err := terminalService.FindAll()
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"terminals": terminals,
+	})
+	return nil
+}
+
+func registerTerminal(w http.ResponseWriter, r *http.Request, terminalService terminal.TerminalService) error {
+	log.Println("-> new request to register POS terminal")
+	var req types.RegisterTerminalRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	terminal, token, err := terminalService.Register(req.Name)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusCreated, map[string]interface{}{
+		"terminal": terminal,
+		"token":    token,
+		"message":  "Configure the token on the terminal, it won't be shown again",
+	})
+	return nil
+}
+
+func revokeTerminal(w http.ResponseWriter, r *http.Request, terminalService terminal.TerminalService) error {
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to revoke POS terminal %d", id)
+
+	if err := terminalService.Revoke(id); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Terminal revoked successfully",
+	})
+	return nil
+}
+
+func listApiKeys(w http.ResponseWriter, r *http.Request, apiKeyService apikey.ApiKeyService) error {
+	keys, err := apiKeyService.FindAll()
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+	})
+	return nil
+}
+
+func createApiKey(w http.ResponseWriter, r *http.Request, apiKeyService apikey.ApiKeyService) error {
+	adminID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to create api key by user %d", adminID)
+	var req types.CreateApiKeyRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	key, plain, err := apiKeyService.Create(req.Name, req.Scopes, req.Allowed_ips, adminID)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+		"key":     plain,
+		"message": "Store the key somewhere safe, it won't be shown again",
+	})
+	return nil
+}
+
+func revokeApiKey(w http.ResponseWriter, r *http.Request, apiKeyService apikey.ApiKeyService) error {
+	id, err := utils.PathInt(r, "id")
+	if err != nil {
+		return err
+	}
+	log.Printf("-> new request to revoke api key %d", id)
+
+	if err := apiKeyService.Revoke(id); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "API key revoked successfully",
+	})
+	return nil
+}
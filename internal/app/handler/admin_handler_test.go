@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestToken(t *testing.T, userID int, role string) string {
+	token, err := auth.CreateJWT(auth.Claims{UserID: userID, Role: role}, auth.AccessToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestUnlockUser(t *testing.T) {
+	mockUserService := &MockUserService{
+		FindByIDFunc: func(id int) (user.User, error) {
+			return user.User{ID: id, Email: "john.doe@example.com"}, nil
+		},
+	}
+
+	tests := []struct {
+		name        string
+		userService *MockUserService
+		token       string
+		method      string
+		wantStatus  int
+	}{
+		{"should return 401 without a token", mockUserService, "", "POST", http.StatusUnauthorized},
+		{"should return 403 for non admin users", mockUserService, newTestToken(t, 2, user.RoleCustomer), "POST", http.StatusForbidden},
+		{"should return 200 for admins", mockUserService, newTestToken(t, 3, user.RoleAdmin), "POST", http.StatusOK},
+		{"should return 404 for unknown users", &MockUserService{}, newTestToken(t, 3, user.RoleAdmin), "POST", http.StatusNotFound},
+		{"should return 400 for other methods", mockUserService, newTestToken(t, 3, user.RoleAdmin), "GET", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := AdminHandler(tt.userService, newTestLockoutService(), &MockTerminalService{}, nil, newTestAuthenticator(tt.userService))
+
+			req, err := http.NewRequest(tt.method, "/api/admin/users/1/unlock", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	var received utils.ListQuery
+	mockUserService := &MockUserService{
+		ListUsersFunc: func(query utils.ListQuery) ([]user.User, int, error) {
+			received = query
+			users := make([]user.User, query.Limit+1)
+			return users, 31, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		query      string
+		wantStatus int
+	}{
+		{"should return 403 for staff below manager", newTestToken(t, 2, user.RoleWaiter), "", http.StatusForbidden},
+		{"should return 200 for managers", newTestToken(t, 3, user.RoleManager), "?role=waiter&name=ana&sort=-created_at&page=2&limit=10", http.StatusOK},
+		{"should return 400 for an unknown role", newTestToken(t, 3, user.RoleManager), "?role=chef", http.StatusBadRequest},
+		{"should return 400 for an unknown sort field", newTestToken(t, 3, user.RoleManager), "?sort=password", http.StatusBadRequest},
+		{"should return 400 for a limit above the maximum", newTestToken(t, 3, user.RoleManager), "?limit=500", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := AdminHandler(mockUserService, newTestLockoutService(), &MockTerminalService{}, nil, newTestAuthenticator(mockUserService))
+
+			req, err := http.NewRequest("GET", "/api/admin/users"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body: %s",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if received.Limit != 10 || received.Page != 2 || received.Sort != "created_at" || !received.Descending ||
+				received.Filters["role"] != "waiter" || received.Filters["name"] != "ana" {
+				t.Errorf("unexpected query: %+v", received)
+			}
+
+			var page utils.Page[user.User]
+			json.NewDecoder(rr.Body).Decode(&page)
+			if len(page.Data) != 10 || page.Meta.Total == nil || *page.Meta.Total != 31 {
+				t.Errorf("unexpected page: %+v", page.Meta)
+			}
+			if page.Links.Next == "" || page.Links.Prev == "" {
+				t.Errorf("expected next and prev links, got %+v", page.Links)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"go-restaurant-management/internal/domain/lockout"
 	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
 	"go-restaurant-management/internal/shared/errors/exceptions"
 	"go-restaurant-management/internal/shared/middleware"
 	"go-restaurant-management/internal/shared/types"
@@ -10,18 +13,83 @@ import (
 	"net/http"
 )
 
-func AuthHandler(userService user.UserService) http.HandlerFunc {
+func AuthHandler(userService user.UserService, lockoutService lockout.LockoutService, authenticator *auth.Authenticator) http.HandlerFunc {
 	routes := map[string]map[string]middleware.HandlerFunc{
 		"/api/auth/register": {
 			"POST": func(w http.ResponseWriter, r *http.Request) error {
 				return register(w, r, userService)
 			},
 		},
-		// "/api/auth/login": {
-		// 	"POST": func(w http.ResponseWriter, r *http.Request) error {
-		// 		return login(w, r, userService)
-		// 	},
-		// },
+		"/api/auth/login": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return login(w, r, userService, lockoutService)
+			},
+		},
+		"/api/auth/refresh": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return refresh(w, r, userService)
+			},
+		},
+		"/api/auth/forgot-password": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return forgotPassword(w, r, userService)
+			},
+		},
+		"/api/auth/reset-password": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return resetPassword(w, r, userService)
+			},
+		},
+		"/api/auth/verify": {
+			"GET": func(w http.ResponseWriter, r *http.Request) error {
+				return verifyEmail(w, r, userService)
+			},
+		},
+		"/api/auth/verify/resend": {
+			"POST": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return resendVerification(w, r, userService)
+			}),
+		},
+		"/api/auth/logout": {
+			"POST": posAuthenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return logout(w, r, userService)
+			}),
+		},
+		"/api/auth/logout-all": {
+			"POST": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return logoutAll(w, r, userService)
+			}),
+		},
+		"/api/auth/sessions": {
+			"GET": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return listSessions(w, r, userService)
+			}),
+		},
+		"/api/auth/2fa/verify": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return verifyTwoFactor(w, r, userService, lockoutService)
+			},
+		},
+		"/api/auth/2fa/setup": {
+			"POST": enrolling(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return setupTwoFactor(w, r, userService)
+			}),
+		},
+		"/api/auth/2fa/enable": {
+			"POST": enrolling(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return enableTwoFactor(w, r, userService)
+			}),
+		},
+		"/api/auth/2fa/disable": {
+			"POST": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return disableTwoFactor(w, r, userService)
+			}),
+		},
+		"/api/auth/2fa/recovery-codes": {
+			"POST": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return regenerateRecoveryCodes(w, r, userService)
+			}),
+		},
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -70,6 +138,187 @@ func register(w http.ResponseWriter, r *http.Request, userService user.UserServi
 	return nil
 }
 
+func login(w http.ResponseWriter, r *http.Request, userService user.UserService, lockoutService lockout.LockoutService) error {
+	log.Println("-> new request to login user")
+	var req types.LoginUserRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		log.Printf("error parsing json: %v", err)
+		return err
+	}
+
+	ip := utils.GetClientIP(r)
+	if err := lockoutService.Check(req.Email, ip); err != nil {
+		return err
+	}
+
+	user, tokens, err := userService.Login(req.Email, req.Password, deviceFromRequest(r))
+	if err != nil {
+		// A two-factor challenge means the password was right
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == "UNAUTHORIZED" {
+			if err := lockoutService.RegisterFailure(req.Email, ip); err != nil {
+				log.Printf("error registering failed login for %s: %v", req.Email, err)
+			}
+		}
+		return err
+	}
+
+	if err := lockoutService.RegisterSuccess(req.Email); err != nil {
+		log.Printf("error clearing failed logins for %s: %v", req.Email, err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, loginResponse(user, tokens))
+	return nil
+}
+
+func loginResponse(user user.User, tokens auth.TokenPair) map[string]interface{} {
+	return map[string]interface{}{
+		"user":          user,
+		"token":         tokens.Token,
+		"refresh_token": tokens.Refresh_token,
+		"expires_in":    tokens.Expires_in,
+	}
+}
+
+func refresh(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	var req types.RefreshTokenRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	_, tokens, err := userService.Refresh(req.Refresh_token, deviceFromRequest(r))
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, tokens)
+	return nil
+}
+
+func logout(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	log.Printf("-> new request to log out user %d", claims.UserID)
+
+	if err := userService.Logout(claims.SessionID); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Logged out successfully",
+	})
+	return nil
+}
+
+func logoutAll(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to log out all devices of user %d", userID)
+
+	revoked, err := userService.LogoutAll(userID)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message":          "Logged out of all devices",
+		"sessions_revoked": revoked,
+	})
+	return nil
+}
+
+func listSessions(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+
+	sessions, err := userService.ListSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+	return nil
+}
+
+func deviceFromRequest(r *http.Request) user.Device {
+	return user.Device{
+		User_agent: r.UserAgent(),
+		Ip:         utils.GetClientIP(r),
+	}
+}
+
+func forgotPassword(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	log.Println("-> new request to recover password")
+	var req types.ForgotPasswordRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	if err := userService.ForgotPassword(req.Email); err != nil {
+		return err
+	}
+
+	// Same answer whether or not the email exists
+	utils.WriteJson(w, http.StatusAccepted, map[string]interface{}{
+		"message": "If the email belongs to an account, a reset link has been sent",
+	})
+	return nil
+}
+
+func resetPassword(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	log.Println("-> new request to reset password")
+	var req types.ResetPasswordRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	if err := userService.ResetPassword(req.Token, req.Password); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Password reset successfully",
+	})
+	return nil
+}
+
+func verifyEmail(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	log.Println("-> new request to verify email")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return exceptions.NewValidationError("token", "The field token is required")
+	}
+
+	user, err := userService.VerifyEmail(token)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"user":    user,
+		"message": "Email verified successfully",
+	})
+	return nil
+}
+
+func resendVerification(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to resend verification email for user %d", userID)
+
+	if err := userService.ResendVerification(userID); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusAccepted, map[string]interface{}{
+		"message": "Verification email sent",
+	})
+	return nil
+}
+
 func validateBusinessRules(req *types.RegisterUserRequest, userService user.UserService) error {
 	// Check if user already exists by email
 	_, err := userService.FindByEmail(req.Email)
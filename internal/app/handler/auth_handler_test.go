@@ -5,11 +5,19 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"go-restaurant-management/internal/domain/lockout"
 	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"go-restaurant-management/internal/shared/storage"
 	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -18,6 +26,42 @@ import (
 type MockUserService struct {
 	RegisterFunc    func(user.User) (user.User, error)
 	FindByEmailFunc func(email string) (user.User, error)
+	FindByIDFunc    func(id int) (user.User, error)
+	LoginFunc       func(email string, password string, device user.Device) (user.User, auth.TokenPair, error)
+	RefreshFunc     func(refreshToken string, device user.Device) (user.User, auth.TokenPair, error)
+
+	ForgotPasswordFunc func(email string) error
+	ResetPasswordFunc  func(token string, password string) error
+
+	VerifyEmailFunc        func(token string) (user.User, error)
+	ResendVerificationFunc func(userID int) error
+
+	VerifyTwoFactorFunc         func(challengeToken string, code string, device user.Device) (user.User, auth.TokenPair, error)
+	SetupTwoFactorFunc          func(userID int) (user.TwoFactorSetup, error)
+	EnableTwoFactorFunc         func(claims auth.Claims, code string, device user.Device) ([]string, auth.TokenPair, error)
+	DisableTwoFactorFunc        func(userID int, password string, code string) error
+	RegenerateRecoveryCodesFunc func(userID int, code string) ([]string, error)
+
+	LogoutFunc          func(sessionID string) error
+	LogoutAllFunc       func(userID int) (int, error)
+	ListSessionsFunc    func(userID int, currentSessionID string) ([]user.Session, error)
+	IsSessionActiveFunc func(sessionID string) bool
+	IsEmailVerifiedFunc func(userID int) bool
+
+	SetPinFunc   func(userID int, password string, pin string) error
+	PinLoginFunc func(userID int, pin string, device user.Device) (user.User, auth.TokenPair, error)
+
+	UpdateProfileFunc  func(u user.User) (user.User, error)
+	ChangePasswordFunc func(userID int, currentPassword string, password string, sessionID string) error
+	UpdateAvatarFunc   func(userID int, content []byte) (user.User, error)
+
+	ListUsersFunc      func(query utils.ListQuery) ([]user.User, int, error)
+	UpdateUserFunc     func(actor auth.Claims, u user.User) (user.User, error)
+	DeactivateUserFunc func(actor auth.Claims, id int) (user.User, error)
+	ReactivateUserFunc func(actor auth.Claims, id int) (user.User, error)
+
+	ExportPersonalDataFunc func(userID int, device user.Device) (user.PersonalDataExport, error)
+	ErasePersonalDataFunc  func(userID int, password string, code string, device user.Device) error
 }
 
 func (m *MockUserService) Register(u user.User) (user.User, error) {
@@ -34,6 +78,221 @@ func (m *MockUserService) FindByEmail(email string) (user.User, error) {
 	return user.User{}, errors.New("user not found")
 }
 
+func (m *MockUserService) FindByID(id int) (user.User, error) {
+	if m.FindByIDFunc != nil {
+		return m.FindByIDFunc(id)
+	}
+	return user.User{}, exceptions.NewEntityNotFound("user", id)
+}
+
+func (m *MockUserService) Login(email string, password string, device user.Device) (user.User, auth.TokenPair, error) {
+	if m.LoginFunc != nil {
+		return m.LoginFunc(email, password, device)
+	}
+	return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid email or password")
+}
+
+func (m *MockUserService) Refresh(refreshToken string, device user.Device) (user.User, auth.TokenPair, error) {
+	if m.RefreshFunc != nil {
+		return m.RefreshFunc(refreshToken, device)
+	}
+	return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid refresh token")
+}
+
+func (m *MockUserService) ForgotPassword(email string) error {
+	if m.ForgotPasswordFunc != nil {
+		return m.ForgotPasswordFunc(email)
+	}
+	return nil
+}
+
+func (m *MockUserService) ResetPassword(token string, password string) error {
+	if m.ResetPasswordFunc != nil {
+		return m.ResetPasswordFunc(token, password)
+	}
+	return exceptions.NewValidationError("token", "The reset token is invalid or has expired")
+}
+
+func (m *MockUserService) VerifyEmail(token string) (user.User, error) {
+	if m.VerifyEmailFunc != nil {
+		return m.VerifyEmailFunc(token)
+	}
+	return user.User{}, exceptions.NewValidationError("token", "The verification token is invalid or has expired")
+}
+
+func (m *MockUserService) ResendVerification(userID int) error {
+	if m.ResendVerificationFunc != nil {
+		return m.ResendVerificationFunc(userID)
+	}
+	return nil
+}
+
+func (m *MockUserService) VerifyTwoFactor(challengeToken string, code string, device user.Device) (user.User, auth.TokenPair, error) {
+	if m.VerifyTwoFactorFunc != nil {
+		return m.VerifyTwoFactorFunc(challengeToken, code, device)
+	}
+	return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid two-factor code")
+}
+
+func (m *MockUserService) SetupTwoFactor(userID int) (user.TwoFactorSetup, error) {
+	if m.SetupTwoFactorFunc != nil {
+		return m.SetupTwoFactorFunc(userID)
+	}
+	return user.TwoFactorSetup{}, nil
+}
+
+func (m *MockUserService) EnableTwoFactor(claims auth.Claims, code string, device user.Device) ([]string, auth.TokenPair, error) {
+	if m.EnableTwoFactorFunc != nil {
+		return m.EnableTwoFactorFunc(claims, code, device)
+	}
+	return nil, auth.TokenPair{}, nil
+}
+
+func (m *MockUserService) DisableTwoFactor(userID int, password string, code string) error {
+	if m.DisableTwoFactorFunc != nil {
+		return m.DisableTwoFactorFunc(userID, password, code)
+	}
+	return nil
+}
+
+func (m *MockUserService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if m.RegenerateRecoveryCodesFunc != nil {
+		return m.RegenerateRecoveryCodesFunc(userID, code)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) Logout(sessionID string) error {
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(sessionID)
+	}
+	return nil
+}
+
+func (m *MockUserService) LogoutAll(userID int) (int, error) {
+	if m.LogoutAllFunc != nil {
+		return m.LogoutAllFunc(userID)
+	}
+	return 0, nil
+}
+
+func (m *MockUserService) ListSessions(userID int, currentSessionID string) ([]user.Session, error) {
+	if m.ListSessionsFunc != nil {
+		return m.ListSessionsFunc(userID, currentSessionID)
+	}
+	return []user.Session{}, nil
+}
+
+func (m *MockUserService) IsSessionActive(sessionID string) bool {
+	if m.IsSessionActiveFunc != nil {
+		return m.IsSessionActiveFunc(sessionID)
+	}
+	return true
+}
+
+func (m *MockUserService) IsEmailVerified(userID int) bool {
+	if m.IsEmailVerifiedFunc != nil {
+		return m.IsEmailVerifiedFunc(userID)
+	}
+	return true
+}
+
+func (m *MockUserService) Close() {}
+
+func (m *MockUserService) SetPin(userID int, password string, pin string) error {
+	if m.SetPinFunc != nil {
+		return m.SetPinFunc(userID, password, pin)
+	}
+	return nil
+}
+
+func (m *MockUserService) PinLogin(userID int, pin string, device user.Device) (user.User, auth.TokenPair, error) {
+	if m.PinLoginFunc != nil {
+		return m.PinLoginFunc(userID, pin, device)
+	}
+	return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid user or PIN")
+}
+
+func (m *MockUserService) UpdateProfile(u user.User) (user.User, error) {
+	if m.UpdateProfileFunc != nil {
+		return m.UpdateProfileFunc(u)
+	}
+	return u, nil
+}
+
+func (m *MockUserService) ChangePassword(userID int, currentPassword string, password string, sessionID string) error {
+	if m.ChangePasswordFunc != nil {
+		return m.ChangePasswordFunc(userID, currentPassword, password, sessionID)
+	}
+	return nil
+}
+
+func (m *MockUserService) UpdateAvatar(userID int, content []byte) (user.User, error) {
+	if m.UpdateAvatarFunc != nil {
+		return m.UpdateAvatarFunc(userID, content)
+	}
+	return user.User{ID: userID}, nil
+}
+
+func (m *MockUserService) ListUsers(query utils.ListQuery) ([]user.User, int, error) {
+	if m.ListUsersFunc != nil {
+		return m.ListUsersFunc(query)
+	}
+	return []user.User{}, 0, nil
+}
+
+func (m *MockUserService) UpdateUser(actor auth.Claims, u user.User) (user.User, error) {
+	if m.UpdateUserFunc != nil {
+		return m.UpdateUserFunc(actor, u)
+	}
+	return u, nil
+}
+
+func (m *MockUserService) DeactivateUser(actor auth.Claims, id int) (user.User, error) {
+	if m.DeactivateUserFunc != nil {
+		return m.DeactivateUserFunc(actor, id)
+	}
+	return user.User{}, exceptions.NewEntityNotFound("user", id)
+}
+
+func (m *MockUserService) ReactivateUser(actor auth.Claims, id int) (user.User, error) {
+	if m.ReactivateUserFunc != nil {
+		return m.ReactivateUserFunc(actor, id)
+	}
+	return user.User{}, exceptions.NewEntityNotFound("user", id)
+}
+
+func (m *MockUserService) ExportPersonalData(userID int, device user.Device) (user.PersonalDataExport, error) {
+	if m.ExportPersonalDataFunc != nil {
+		return m.ExportPersonalDataFunc(userID, device)
+	}
+	return user.PersonalDataExport{Profile: user.User{ID: userID}}, nil
+}
+
+func (m *MockUserService) ErasePersonalData(userID int, password string, code string, device user.Device) error {
+	if m.ErasePersonalDataFunc != nil {
+		return m.ErasePersonalDataFunc(userID, password, code, device)
+	}
+	return nil
+}
+
+func newTestAuthenticator(sessions auth.SessionChecker) *auth.Authenticator {
+	return auth.NewAuthenticator(sessions, nil)
+}
+
+func newTestLockoutService() lockout.LockoutService {
+	return lockout.NewLockoutService(lockout.NewMemoryLockoutRepository(), lockout.Policy{
+		MaxAccountFailures: 3,
+		MaxIPFailures:      10,
+		DelayAfter:         10,
+		BaseDelay:          time.Second,
+		MaxDelay:           time.Second,
+		LockoutDuration:    time.Minute,
+		MaxLockout:         time.Hour,
+		Window:             time.Hour,
+	})
+}
+
 func TestRegister(t *testing.T) {
 	t.Run("should return 201 when user is registered successfully", func(t *testing.T) {
 		// Create a mock user service
@@ -50,15 +309,15 @@ func TestRegister(t *testing.T) {
 		}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new registration request
 		regReq := types.RegisterUserRequest{
 			First_name: "John",
 			Last_name:  "Doe",
 			Email:      "john.doe@example.com",
-			Password:   "password123", // At least 6 characters
-			Phone:      "12345678901", // Exactly 11 digits
+			Password:   "Bistro#Night42", // Satisfies the default password policy
+			Phone:      "11987654321",    // Valid Brazilian mobile (DDD + 9 digits)
 		}
 
 		// Marshal the request body to JSON
@@ -116,7 +375,7 @@ func TestRegister(t *testing.T) {
 		mockUserService := &MockUserService{}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new HTTP request with an invalid JSON body
 		req, err := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer([]byte(`{"invalid`)))
@@ -154,15 +413,15 @@ func TestRegister(t *testing.T) {
 		mockUserService := &MockUserService{}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new registration request with missing required fields
 		regReq := types.RegisterUserRequest{
 			First_name: "", // Empty first name (should fail validation)
 			Last_name:  "Doe",
 			Email:      "john.doe@example.com",
-			Password:   "password123",
-			Phone:      "12345678901",
+			Password:   "Bistro#Night42",
+			Phone:      "11987654321",
 		}
 
 		// Marshal the request body to JSON
@@ -202,6 +461,62 @@ func TestRegister(t *testing.T) {
 		}
 	})
 
+	t.Run("should return 400 when password does not satisfy the policy", func(t *testing.T) {
+		passwords := map[string]string{
+			"common":           "Welcome123",
+			"short":            "Ab1",
+			"no uppercase":     "bistro#night42",
+			"too many bytes":   "Bistro#Night42" + string(bytes.Repeat([]byte("a"), 60)),
+			"leaked":           "Password123!",
+			"contains name":    "Johnny#Night42",
+			"contains email":   "John.Doe#Night42",
+			"contains surname": "Bistro#Doe42",
+		}
+
+		for name, password := range passwords {
+			t.Run(name, func(t *testing.T) {
+				h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+				regReq := types.RegisterUserRequest{
+					First_name: "John",
+					Last_name:  "Doe",
+					Email:      "john.doe@example.com",
+					Password:   password,
+					Phone:      "11987654321",
+				}
+
+				body, err := json.Marshal(regReq)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				req, err := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Content-Type", "application/json")
+
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+
+				if status := rr.Code; status != http.StatusBadRequest {
+					t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+						status, http.StatusBadRequest, rr.Body.String())
+				}
+
+				var errorResponse map[string]interface{}
+				if err := json.Unmarshal(rr.Body.Bytes(), &errorResponse); err != nil {
+					t.Fatal(err)
+				}
+
+				details, _ := errorResponse["details"].(map[string]interface{})
+				if errorResponse["code"] != "VALIDATION_ERROR" || details["field"] != "password" {
+					t.Errorf("expected VALIDATION_ERROR on password, got %v", errorResponse)
+				}
+			})
+		}
+	})
+
 	t.Run("should return 500 when user service returns an error", func(t *testing.T) {
 		// Create a mock user service
 		mockUserService := &MockUserService{
@@ -215,15 +530,15 @@ func TestRegister(t *testing.T) {
 		}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new registration request
 		regReq := types.RegisterUserRequest{
 			First_name: "John",
 			Last_name:  "Doe",
 			Email:      "john.doe@example.com",
-			Password:   "password123",
-			Phone:      "12345678901",
+			Password:   "Bistro#Night42",
+			Phone:      "11987654321",
 		}
 
 		// Marshal the request body to JSON
@@ -257,7 +572,7 @@ func TestRegister(t *testing.T) {
 		mockUserService := &MockUserService{}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new HTTP request with a GET method
 		req, err := http.NewRequest("GET", "/api/auth/register", nil)
@@ -302,15 +617,15 @@ func TestRegister(t *testing.T) {
 		}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new registration request
 		regReq := types.RegisterUserRequest{
 			First_name: "Jane",
 			Last_name:  "Doe",
 			Email:      "jane.doe@example.com",
-			Password:   "password123",
-			Phone:      "09876543210",
+			Password:   "Bistro#Night42",
+			Phone:      "21998765432",
 		}
 
 		// Marshal the request body to JSON
@@ -355,7 +670,7 @@ func TestRegister(t *testing.T) {
 		mockUserService := &MockUserService{}
 
 		// Create a new HTTP handler with the mock service
-		h := AuthHandler(mockUserService)
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
 
 		// Create a new HTTP request with wrong path
 		req, err := http.NewRequest("POST", "/api/auth/invalid", nil)
@@ -388,6 +703,499 @@ func TestRegister(t *testing.T) {
 	})
 }
 
+func TestLogin(t *testing.T) {
+	t.Run("should return 200 with tokens when credentials are valid", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			LoginFunc: func(email string, password string, device user.Device) (user.User, auth.TokenPair, error) {
+				return user.User{ID: 1, Email: email}, auth.TokenPair{Token: "access", Refresh_token: "refresh", Expires_in: 3600}, nil
+			},
+		}
+
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		body, err := json.Marshal(types.LoginUserRequest{Email: "john.doe@example.com", Password: "Bistro#Night42"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				status, http.StatusOK, rr.Body.String())
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+
+		if response["token"] != "access" || response["refresh_token"] != "refresh" {
+			t.Errorf("response should contain the issued tokens, got %v", response)
+		}
+	})
+
+	t.Run("should return 401 when credentials are invalid", func(t *testing.T) {
+		h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		body, err := json.Marshal(types.LoginUserRequest{Email: "john.doe@example.com", Password: "wrong"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				status, http.StatusUnauthorized, rr.Body.String())
+		}
+	})
+
+	t.Run("should return 400 when email is missing", func(t *testing.T) {
+		h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		req, err := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer([]byte(`{"password":"Bistro#Night42"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				status, http.StatusBadRequest, rr.Body.String())
+		}
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	login := func(h http.HandlerFunc, email string, password string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(types.LoginUserRequest{Email: email, Password: password})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "203.0.113.7:51234"
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	mockUserService := &MockUserService{
+		LoginFunc: func(email string, password string, device user.Device) (user.User, auth.TokenPair, error) {
+			if password == "Bistro#Night42" {
+				return user.User{ID: 1, Email: email}, auth.TokenPair{Token: "access"}, nil
+			}
+			return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid email or password")
+		},
+	}
+
+	t.Run("should return 429 with Retry-After once the account is locked", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		for i := 0; i < 3; i++ {
+			if rr := login(h, "john.doe@example.com", "wrong"); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d: expected 401, got %v", i+1, rr.Code)
+			}
+		}
+
+		// Even the right password is refused while locked
+		rr := login(h, "john.doe@example.com", "Bistro#Night42")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("handler returned wrong status code: got %v want %v, body: %s",
+				rr.Code, http.StatusTooManyRequests, rr.Body.String())
+		}
+
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("response should contain the Retry-After header")
+		}
+
+		var errorResponse map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &errorResponse); err != nil {
+			t.Fatal(err)
+		}
+		if errorResponse["code"] != "TOO_MANY_REQUESTS" {
+			t.Errorf("expected TOO_MANY_REQUESTS error, got %v", errorResponse["code"])
+		}
+
+		// Other accounts are not affected
+		if rr := login(h, "jane.doe@example.com", "Bistro#Night42"); rr.Code != http.StatusOK {
+			t.Errorf("expected other accounts to log in, got %v", rr.Code)
+		}
+	})
+
+	t.Run("should reset the counter after a successful login", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		for i := 0; i < 2; i++ {
+			login(h, "john.doe@example.com", "wrong")
+		}
+		if rr := login(h, "john.doe@example.com", "Bistro#Night42"); rr.Code != http.StatusOK {
+			t.Fatalf("expected successful login, got %v", rr.Code)
+		}
+		for i := 0; i < 2; i++ {
+			login(h, "john.doe@example.com", "wrong")
+		}
+
+		if rr := login(h, "john.doe@example.com", "Bistro#Night42"); rr.Code != http.StatusOK {
+			t.Errorf("expected the account not to be locked, got %v", rr.Code)
+		}
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	post := func(h http.HandlerFunc, path string, payload interface{}) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should return the same 202 response whether the email exists or not", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			ForgotPasswordFunc: func(email string) error {
+				return nil
+			},
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		known := post(h, "/api/auth/forgot-password", types.ForgotPasswordRequest{Email: "john.doe@example.com"})
+		unknown := post(h, "/api/auth/forgot-password", types.ForgotPasswordRequest{Email: "nobody@example.com"})
+
+		if known.Code != http.StatusAccepted || unknown.Code != http.StatusAccepted {
+			t.Errorf("expected 202 for both emails, got %v and %v", known.Code, unknown.Code)
+		}
+		if known.Body.String() != unknown.Body.String() {
+			t.Errorf("responses should be identical, got %s and %s", known.Body.String(), unknown.Body.String())
+		}
+	})
+
+	t.Run("should return 200 when the password is reset", func(t *testing.T) {
+		var gotToken, gotPassword string
+		mockUserService := &MockUserService{
+			ResetPasswordFunc: func(token string, password string) error {
+				gotToken, gotPassword = token, password
+				return nil
+			},
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		rr := post(h, "/api/auth/reset-password", types.ResetPasswordRequest{Token: "reset-token", Password: "Bistro#Night42"})
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if gotToken != "reset-token" || gotPassword != "Bistro#Night42" {
+			t.Errorf("service received unexpected arguments: %q, %q", gotToken, gotPassword)
+		}
+	})
+
+	t.Run("should return 400 when the token is invalid", func(t *testing.T) {
+		h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		rr := post(h, "/api/auth/reset-password", types.ResetPasswordRequest{Token: "used-token", Password: "Bistro#Night42"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				rr.Code, http.StatusBadRequest, rr.Body.String())
+		}
+	})
+
+	t.Run("should return 400 when the new password is weak", func(t *testing.T) {
+		h := AuthHandler(&MockUserService{ResetPasswordFunc: func(string, string) error { return nil }}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		// Long enough and mixed, only the common password rule refuses it
+		rr := post(h, "/api/auth/reset-password", types.ResetPasswordRequest{Token: "reset-token", Password: "Welcome123"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				rr.Code, http.StatusBadRequest, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), "too common") {
+			t.Errorf("expected the common password rule to refuse it, got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestEmailVerification(t *testing.T) {
+	t.Run("should return 200 when the token is valid", func(t *testing.T) {
+		now := time.Now()
+		mockUserService := &MockUserService{
+			VerifyEmailFunc: func(token string) (user.User, error) {
+				if token != "verification-token" {
+					t.Errorf("unexpected token %q", token)
+				}
+				return user.User{ID: 1, Email: "john.doe@example.com", Verified_at: &now}, nil
+			},
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		req, err := http.NewRequest("GET", "/api/auth/verify?token=verification-token", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v, body: %s",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+	})
+
+	t.Run("should return 400 when the token is missing or invalid", func(t *testing.T) {
+		h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		for _, path := range []string{"/api/auth/verify", "/api/auth/verify?token=expired"} {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", path, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("should require authentication to resend the email", func(t *testing.T) {
+		var resentFor int
+		mockUserService := &MockUserService{
+			ResendVerificationFunc: func(userID int) error {
+				resentFor = userID
+				return nil
+			},
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		req, err := http.NewRequest("POST", "/api/auth/verify/resend", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 without token, got %v", rr.Code)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+newTestToken(t, 7, user.RoleCustomer))
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusAccepted || resentFor != 7 {
+			t.Errorf("expected 202 for user 7, got %v for user %d", rr.Code, resentFor)
+		}
+	})
+}
+
+func TestTwoFactor(t *testing.T) {
+	post := func(h http.HandlerFunc, path string, token string, payload interface{}) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	manager := user.User{ID: 5, Email: "manager@example.com", Role: user.RoleManager}
+	challenge, err := auth.CreateJWT(manager.Claims(), auth.ChallengeToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockUserService := &MockUserService{
+		FindByIDFunc: func(id int) (user.User, error) {
+			return manager, nil
+		},
+		LoginFunc: func(email string, password string, device user.Device) (user.User, auth.TokenPair, error) {
+			return user.User{}, auth.TokenPair{}, exceptions.NewTwoFactorRequiredError(challenge, false)
+		},
+		VerifyTwoFactorFunc: func(challengeToken string, code string, device user.Device) (user.User, auth.TokenPair, error) {
+			if code == "123456" {
+				return manager, auth.TokenPair{Token: "access"}, nil
+			}
+			return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid two-factor code")
+		},
+		EnableTwoFactorFunc: func(claims auth.Claims, code string, device user.Device) ([]string, auth.TokenPair, error) {
+			if claims.Type == auth.ChallengeToken {
+				return []string{"ABCDE-FGHJK"}, auth.TokenPair{Token: "access"}, nil
+			}
+			return []string{"ABCDE-FGHJK"}, auth.TokenPair{}, nil
+		},
+	}
+
+	t.Run("should not count a two-factor challenge as a failed login", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		for i := 0; i < 4; i++ {
+			rr := post(h, "/api/auth/login", "", map[string]string{"email": manager.Email, "password": "Bistro#Night42"})
+			if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "TWO_FACTOR_REQUIRED") {
+				t.Fatalf("attempt %d: expected two-factor challenge, got %v: %s", i+1, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("should lock the account after too many wrong codes", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		for i := 0; i < 3; i++ {
+			if rr := post(h, "/api/auth/2fa/verify", "", map[string]string{"challenge_token": challenge, "code": "000000"}); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d: expected 401, got %v", i+1, rr.Code)
+			}
+		}
+
+		if rr := post(h, "/api/auth/2fa/verify", "", map[string]string{"challenge_token": challenge, "code": "123456"}); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected 429, got %v", rr.Code)
+		}
+	})
+
+	t.Run("should complete the login with a valid code", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		rr := post(h, "/api/auth/2fa/verify", "", map[string]string{"challenge_token": challenge, "code": "123456"})
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"token":"access"`) {
+			t.Errorf("expected tokens, got %v: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("should accept challenge tokens only for enrollment", func(t *testing.T) {
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		rr := post(h, "/api/auth/2fa/enable", challenge, map[string]string{"code": "123456"})
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"token":"access"`) {
+			t.Errorf("expected recovery codes and tokens, got %v: %s", rr.Code, rr.Body.String())
+		}
+
+		if rr := post(h, "/api/auth/2fa/disable", challenge, map[string]string{"password": "x", "code": "123456"}); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected challenge token to be refused, got %v", rr.Code)
+		}
+		if rr := post(h, "/api/auth/2fa/disable", newTestToken(t, 5, user.RoleManager), map[string]string{"password": "x", "code": "123456"}); rr.Code != http.StatusOK {
+			t.Errorf("expected access token to be accepted, got %v", rr.Code)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	newSessionToken := func(t *testing.T, userID int, sessionID string) string {
+		token, err := auth.CreateJWT(auth.Claims{UserID: userID, Role: user.RoleWaiter, SessionID: sessionID}, auth.AccessToken, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	request := func(h http.HandlerFunc, method string, path string, token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("should revoke the current session on logout", func(t *testing.T) {
+		revoked := map[string]bool{}
+		mockUserService := &MockUserService{
+			LogoutFunc: func(sessionID string) error {
+				revoked[sessionID] = true
+				return nil
+			},
+			IsSessionActiveFunc: func(sessionID string) bool { return !revoked[sessionID] },
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+		tablet := newSessionToken(t, 3, "tablet")
+		phone := newSessionToken(t, 3, "phone")
+
+		if rr := request(h, "POST", "/api/auth/logout", tablet); rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v: %s", rr.Code, rr.Body.String())
+		}
+
+		// The access token is still within its lifetime but its session is gone
+		if rr := request(h, "GET", "/api/auth/sessions", tablet); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected revoked session to be refused, got %v", rr.Code)
+		}
+		if rr := request(h, "GET", "/api/auth/sessions", phone); rr.Code != http.StatusOK {
+			t.Errorf("expected other sessions to keep working, got %v", rr.Code)
+		}
+	})
+
+	t.Run("should mark the current session in the list", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			ListSessionsFunc: func(userID int, currentSessionID string) ([]user.Session, error) {
+				return []user.Session{
+					{ID: "tablet", User_id: userID, Current: currentSessionID == "tablet"},
+					{ID: "phone", User_id: userID, Current: currentSessionID == "phone"},
+				}, nil
+			},
+		}
+		h := AuthHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService))
+
+		rr := request(h, "GET", "/api/auth/sessions", newSessionToken(t, 3, "phone"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v", rr.Code)
+		}
+
+		var response struct {
+			Sessions []user.Session `json:"sessions"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Sessions) != 2 || response.Sessions[0].Current || !response.Sessions[1].Current {
+			t.Errorf("unexpected sessions %+v", response.Sessions)
+		}
+	})
+}
+
 func TestRegisterIntegration(t *testing.T) {
 	// Setup do banco de teste
 	db, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:3306)/restaurant-test")
@@ -410,15 +1218,15 @@ func TestRegisterIntegration(t *testing.T) {
 	t.Run("should register user successfully with real database", func(t *testing.T) {
 		// Usar o repository real
 		userRepo := user.NewUserRepository(db)
-		userService := user.NewUserService(userRepo)
-		h := AuthHandler(userService)
+		userService := user.NewUserService(userRepo, user.NewUserTokenRepository(db), user.NewRecoveryCodeRepository(db), user.NewSessionRepository(db), user.NewDataRequestRepository(db), notifier.NewConsoleNotifier(), storage.NewLocalStorage(t.TempDir(), "/uploads"))
+		h := AuthHandler(userService, newTestLockoutService(), newTestAuthenticator(userService))
 
 		regReq := types.RegisterUserRequest{
 			First_name: "Integration",
 			Last_name:  "Test",
 			Email:      "integration.test@example.com",
-			Password:   "password123",
-			Phone:      "12345678901", // Exactly 11 digits
+			Password:   "Bistro#Night42",
+			Phone:      "11987654321", // Valid Brazilian mobile (DDD + 9 digits)
 		}
 
 		body, err := json.Marshal(regReq)
@@ -461,15 +1269,15 @@ func TestRegisterIntegration(t *testing.T) {
 	t.Run("should return 409 when trying to register duplicate email", func(t *testing.T) {
 		// Usar o repository real
 		userRepo := user.NewUserRepository(db)
-		userService := user.NewUserService(userRepo)
-		h := AuthHandler(userService)
+		userService := user.NewUserService(userRepo, user.NewUserTokenRepository(db), user.NewRecoveryCodeRepository(db), user.NewSessionRepository(db), user.NewDataRequestRepository(db), notifier.NewConsoleNotifier(), storage.NewLocalStorage(t.TempDir(), "/uploads"))
+		h := AuthHandler(userService, newTestLockoutService(), newTestAuthenticator(userService))
 
 		regReq := types.RegisterUserRequest{
 			First_name: "Duplicate",
 			Last_name:  "Test",
 			Email:      "duplicate.test@example.com",
-			Password:   "password123",
-			Phone:      "12345678901",
+			Password:   "Bistro#Night42",
+			Phone:      "11987654321",
 		}
 
 		// First registration - should succeed
@@ -493,7 +1301,7 @@ func TestRegisterIntegration(t *testing.T) {
 		}
 
 		// Second registration with same email - should fail
-		regReq.Phone = "10987654321" // Different phone
+		regReq.Phone = "11912345678" // Different phone
 		body, err = json.Marshal(regReq)
 		if err != nil {
 			t.Fatal(err)
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/terminal"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/middleware"
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// PinHandler serves the staff PIN login used on shared POS terminals.
+// pinLockoutService should use lockout.PinPolicy.
+func PinHandler(userService user.UserService, terminalService terminal.TerminalService, pinLockoutService lockout.LockoutService, authenticator *auth.Authenticator) http.HandlerFunc {
+	routes := map[string]map[string]middleware.HandlerFunc{
+		"/api/auth/pin": {
+			"PUT": authenticated(authenticator, func(w http.ResponseWriter, r *http.Request) error {
+				return setPin(w, r, userService)
+			}),
+		},
+		"/api/auth/pin-login": {
+			"POST": func(w http.ResponseWriter, r *http.Request) error {
+				return pinLogin(w, r, userService, terminalService, pinLockoutService)
+			},
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		method := r.Method
+
+		if pathRoutes, exists := routes[path]; exists {
+			if handler, methodExists := pathRoutes[method]; methodExists {
+				middleware.ErrorHandlerFunc(handler)(w, r)
+				return
+			}
+			utils.WriteError(w, exceptions.NewMethodNotAllowedError(method, path))
+			return
+		}
+		utils.WriteError(w, exceptions.NewRouteNotFoundError(path))
+	}
+}
+
+func setPin(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to set PIN for user %d", userID)
+	var req types.SetPinRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	if err := userService.SetPin(userID, req.Password, req.Pin); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "PIN set successfully",
+	})
+	return nil
+}
+
+func pinLogin(w http.ResponseWriter, r *http.Request, userService user.UserService, terminalService terminal.TerminalService, pinLockoutService lockout.LockoutService) error {
+	log.Println("-> new request to login with PIN")
+
+	posTerminal, err := terminalService.Authenticate(r.Header.Get("X-Terminal-Token"))
+	if err != nil {
+		return err
+	}
+
+	var req types.PinLoginRequest
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	// Failures are counted per user and per terminal
+	account := strconv.Itoa(req.User_id)
+	terminalKey := "terminal-" + strconv.Itoa(posTerminal.ID)
+	if err := pinLockoutService.Check(account, terminalKey); err != nil {
+		return err
+	}
+
+	device := user.Device{User_agent: "POS terminal " + posTerminal.Name, Ip: utils.GetClientIP(r)}
+	user, tokens, err := userService.PinLogin(req.User_id, req.Pin, device)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.UNAUTHORIZED {
+			if err := pinLockoutService.RegisterFailure(account, terminalKey); err != nil {
+				log.Printf("error registering failed PIN login for user %d: %v", req.User_id, err)
+			}
+		}
+		return err
+	}
+
+	if err := pinLockoutService.RegisterSuccess(account); err != nil {
+		log.Printf("error clearing failed PIN logins for user %d: %v", req.User_id, err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"user":       user,
+		"token":      tokens.Token,
+		"expires_in": tokens.Expires_in,
+	})
+	return nil
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/terminal"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// MockTerminalService accepts the "tablet-token" and "bar-token" terminal
+// tokens.
+type MockTerminalService struct{}
+
+func (m *MockTerminalService) Register(name string) (terminal.Terminal, string, error) {
+	return terminal.Terminal{ID: 1, Name: name}, "tablet-token", nil
+}
+
+func (m *MockTerminalService) Authenticate(token string) (terminal.Terminal, error) {
+	switch token {
+	case "tablet-token":
+		return terminal.Terminal{ID: 1, Name: "Tablet 1"}, nil
+	case "bar-token":
+		return terminal.Terminal{ID: 2, Name: "Bar"}, nil
+	}
+	return terminal.Terminal{}, exceptions.NewUnauthorizedError("unknown or revoked terminal")
+}
+
+func (m *MockTerminalService) FindAll() ([]terminal.Terminal, error) {
+	return []terminal.Terminal{}, nil
+}
+
+func (m *MockTerminalService) Revoke(id int) error {
+	return nil
+}
+
+func TestPinLogin(t *testing.T) {
+	pinLogin := func(h http.HandlerFunc, terminalToken string, userID int, pin string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(map[string]interface{}{"user_id": userID, "pin": pin})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest("POST", "/api/auth/pin-login", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Terminal-Token", terminalToken)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	pinLockoutService := func() lockout.LockoutService {
+		return lockout.NewLockoutService(lockout.NewMemoryLockoutRepository(), lockout.Policy{
+			Scope:              "pin",
+			MaxAccountFailures: 3,
+			MaxIPFailures:      10,
+			DelayAfter:         10,
+			LockoutDuration:    time.Minute,
+			MaxLockout:         time.Hour,
+			Window:             time.Hour,
+		})
+	}
+
+	mockUserService := &MockUserService{
+		PinLoginFunc: func(userID int, pin string, device user.Device) (user.User, auth.TokenPair, error) {
+			if pin == "4821" {
+				return user.User{ID: userID, Role: user.RoleWaiter}, auth.TokenPair{Token: "pos-token", Expires_in: 900}, nil
+			}
+			return user.User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid user or PIN")
+		},
+	}
+
+	t.Run("should only work from registered terminals", func(t *testing.T) {
+		h := PinHandler(mockUserService, &MockTerminalService{}, pinLockoutService(), newTestAuthenticator(mockUserService))
+
+		if rr := pinLogin(h, "", 4, "4821"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 without terminal token, got %v", rr.Code)
+		}
+		if rr := pinLogin(h, "revoked-token", 4, "4821"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 from a revoked terminal, got %v", rr.Code)
+		}
+		if rr := pinLogin(h, "tablet-token", 4, "4821"); rr.Code != http.StatusOK {
+			t.Errorf("expected 200 from a registered terminal, got %v: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("should refuse PINs that aren't only digits", func(t *testing.T) {
+		h := PinHandler(mockUserService, &MockTerminalService{}, pinLockoutService(), newTestAuthenticator(mockUserService))
+
+		for _, pin := range []string{"-123", "+123", "1.50", "12 34"} {
+			if rr := pinLogin(h, "tablet-token", 4, pin); rr.Code != http.StatusBadRequest {
+				t.Errorf("%q: expected 400, got %v: %s", pin, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("should lock the user after a few wrong PINs", func(t *testing.T) {
+		h := PinHandler(mockUserService, &MockTerminalService{}, pinLockoutService(), newTestAuthenticator(mockUserService))
+
+		for i := 0; i < 3; i++ {
+			if rr := pinLogin(h, "tablet-token", 4, "1111"); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d: expected 401, got %v", i+1, rr.Code)
+			}
+		}
+
+		if rr := pinLogin(h, "tablet-token", 4, "4821"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected 429, got %v", rr.Code)
+		}
+		if rr := pinLogin(h, "tablet-token", 5, "4821"); rr.Code != http.StatusOK {
+			t.Errorf("expected other users to log in, got %v", rr.Code)
+		}
+	})
+
+	t.Run("should lock the terminal after wrong PINs for many users", func(t *testing.T) {
+		h := PinHandler(mockUserService, &MockTerminalService{}, pinLockoutService(), newTestAuthenticator(mockUserService))
+
+		for userID := 10; userID < 20; userID++ {
+			if rr := pinLogin(h, "tablet-token", userID, "1111"); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("user %d: expected 401, got %v", userID, rr.Code)
+			}
+		}
+
+		if rr := pinLogin(h, "tablet-token", 4, "4821"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected 429 on the locked terminal, got %v", rr.Code)
+		}
+		if rr := pinLogin(h, "bar-token", 4, "4821"); rr.Code != http.StatusOK {
+			t.Errorf("expected other terminals to work, got %v: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("should count PIN failures apart from password failures", func(t *testing.T) {
+		repository := lockout.NewMemoryLockoutRepository()
+		h := PinHandler(mockUserService, &MockTerminalService{}, lockout.NewLockoutService(repository, lockout.PinPolicy()), newTestAuthenticator(mockUserService))
+
+		pinLogin(h, "tablet-token", 4, "1111")
+
+		tests := []struct {
+			key  string
+			want int
+		}{
+			{"pin:account:4", 1},
+			{"pin:ip:terminal-1", 1},
+			{"account:4", 0},
+		}
+		for _, tt := range tests {
+			attempt, err := repository.Find(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attempt.Failures != tt.want {
+				t.Errorf("%s: expected %d failures, got %d", tt.key, tt.want, attempt.Failures)
+			}
+		}
+	})
+
+	t.Run("should refuse POS tokens outside POS routes", func(t *testing.T) {
+		token, err := auth.CreateJWT(auth.Claims{UserID: 4, Role: user.RoleWaiter, Scope: auth.ScopePOS}, auth.AccessToken, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authenticator := newTestAuthenticator(&MockUserService{})
+		ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+			want    int
+		}{
+			{"WithJwtAuth", authenticator.WithJwtAuth(ok), http.StatusForbidden},
+			{"WithPOSAuth", authenticator.WithPOSAuth(ok), http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, rr.Code)
+			}
+		}
+	})
+
+	t.Run("should limit POS tokens to POS actions", func(t *testing.T) {
+		token, err := auth.CreateJWT(auth.Claims{UserID: 4, Role: user.RoleWaiter, Scope: auth.ScopePOS}, auth.AccessToken, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := AuthHandler(&MockUserService{}, newTestLockoutService(), newTestAuthenticator(&MockUserService{}))
+
+		tests := []struct {
+			method string
+			path   string
+			want   int
+		}{
+			{"GET", "/api/auth/sessions", http.StatusForbidden},
+			{"POST", "/api/auth/logout", http.StatusOK},
+		}
+
+		for _, tt := range tests {
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("%s: expected %v, got %v", tt.path, tt.want, rr.Code)
+			}
+		}
+	})
+}
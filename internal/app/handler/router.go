@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/middleware"
+	"go-restaurant-management/internal/shared/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// newRouter is used by handlers whose routes carry path parameters. It
+// reports unknown routes and methods with the same errors as AuthHandler.
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, exceptions.NewRouteNotFoundError(r.URL.Path))
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, exceptions.NewMethodNotAllowedError(r.Method, r.URL.Path))
+	})
+	return router
+}
+
+// scoped wraps a handler so it accepts users with one of the roles and API
+// keys granted the scope. Keys have no role, they are only checked by scope.
+func scoped(authenticator *auth.Authenticator, scope string, roles []string, h middleware.HandlerFunc) http.HandlerFunc {
+	usersByRole := func(next http.HandlerFunc) http.HandlerFunc {
+		withRole := auth.WithRole(roles...)(next)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if claims, ok := auth.GetClaimsFromContext(r.Context()); ok && claims.APIKeyID != 0 {
+				next(w, r)
+				return
+			}
+			withRole(w, r)
+		}
+	}
+
+	return utils.Compose(
+		middleware.ErrorHandlerFunc(h),
+		middleware.ErrorHandler,
+		authenticator.WithScope(scope),
+		usersByRole,
+	)
+}
+
+// authenticated wraps an entry of a route map so it requires a valid access token.
+func authenticated(authenticator *auth.Authenticator, h middleware.HandlerFunc) middleware.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		authenticator.WithJwtAuth(middleware.ErrorHandlerFunc(h))(w, r)
+		return nil
+	}
+}
+
+// enrolling is like authenticated but also accepts the challenge token of a
+// login waiting for two-factor enrollment.
+func enrolling(authenticator *auth.Authenticator, h middleware.HandlerFunc) middleware.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		authenticator.WithTokenTypes(auth.AccessToken, auth.ChallengeToken)(middleware.ErrorHandlerFunc(h))(w, r)
+		return nil
+	}
+}
+
+// posAuthenticated is like authenticated but also accepts the POS scoped
+// tokens of PIN logins.
+func posAuthenticated(authenticator *auth.Authenticator, h middleware.HandlerFunc) middleware.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		authenticator.WithPOSAuth(middleware.ErrorHandlerFunc(h))(w, r)
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"go-restaurant-management/internal/domain/apikey"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryApiKeyRepository keeps API keys in memory, so the real service
+// hashes, looks up and restricts them.
+type memoryApiKeyRepository struct {
+	keys []apikey.ApiKey
+}
+
+func (m *memoryApiKeyRepository) Save(key apikey.ApiKey) (apikey.ApiKey, error) {
+	key.ID = len(m.keys) + 1
+	m.keys = append(m.keys, key)
+	return key, nil
+}
+
+func (m *memoryApiKeyRepository) FindByID(id int) (apikey.ApiKey, error) {
+	if id < 1 || id > len(m.keys) {
+		return apikey.ApiKey{}, exceptions.NewEntityNotFound("api key", id)
+	}
+	return m.keys[id-1], nil
+}
+
+func (m *memoryApiKeyRepository) FindActiveByPrefix(prefix string) (apikey.ApiKey, error) {
+	for _, key := range m.keys {
+		if key.Prefix == prefix && key.RevokedAt == nil {
+			return key, nil
+		}
+	}
+	return apikey.ApiKey{}, exceptions.NewEntityNotFound("api key", prefix)
+}
+
+func (m *memoryApiKeyRepository) FindAll() ([]apikey.ApiKey, error) {
+	return m.keys, nil
+}
+
+func (m *memoryApiKeyRepository) Revoke(id int) error {
+	now := time.Now()
+	m.keys[id-1].RevokedAt = &now
+	return nil
+}
+
+func (m *memoryApiKeyRepository) Touch(id int, usedAt time.Time) error {
+	m.keys[id-1].LastUsedAt = &usedAt
+	return nil
+}
+
+func TestScoped(t *testing.T) {
+	apiKeyService := apikey.NewApiKeyService(&memoryApiKeyRepository{})
+	userService := &MockUserService{}
+	authenticator := auth.NewAuthenticator(userService, apiKeyService)
+
+	_, kiosk, err := apiKeyService.Create("Kiosk", []string{apikey.ScopeMenusRead}, []string{"198.51.100.0/24"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedPlain, err := apiKeyService.Create("Old kiosk", []string{apikey.ScopeMenusRead}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := apiKeyService.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	router := newRouter()
+	router.HandleFunc("/api/menus", scoped(authenticator, apikey.ScopeMenusRead, []string{user.RoleAdmin, user.RoleManager}, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	})).Methods(http.MethodGet)
+	router.HandleFunc("/api/orders", scoped(authenticator, apikey.ScopeOrdersWrite, []string{user.RoleWaiter}, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	})).Methods(http.MethodGet)
+
+	tests := []struct {
+		name       string
+		path       string
+		apiKey     string
+		token      string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"should accept keys with the scope", "/api/menus", kiosk, "", "198.51.100.7:1000", http.StatusOK},
+		{"should refuse keys without the scope", "/api/orders", kiosk, "", "198.51.100.7:1000", http.StatusForbidden},
+		{"should refuse keys from other addresses", "/api/menus", kiosk, "", "203.0.113.9:1000", http.StatusForbidden},
+		{"should refuse revoked keys", "/api/menus", revokedPlain, "", "198.51.100.7:1000", http.StatusUnauthorized},
+		{"should refuse made up keys", "/api/menus", "rk_madeup_secret", "", "198.51.100.7:1000", http.StatusUnauthorized},
+		{"should accept users with the role", "/api/menus", "", newTestToken(t, 3, user.RoleManager), "198.51.100.7:1000", http.StatusOK},
+		{"should refuse users without the role", "/api/menus", "", newTestToken(t, 2, user.RoleCustomer), "198.51.100.7:1000", http.StatusForbidden},
+		{"should refuse anonymous requests", "/api/menus", "", "", "198.51.100.7:1000", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"net/http"
+)
+
+func verifyTwoFactor(w http.ResponseWriter, r *http.Request, userService user.UserService, lockoutService lockout.LockoutService) error {
+	log.Println("-> new request to verify two-factor code")
+	var req types.TwoFactorVerifyRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	// Wrong codes count as failed logins, so codes can't be brute forced
+	claims, err := auth.ParseJWT(req.Challenge_token, auth.ChallengeToken)
+	if err != nil {
+		return exceptions.NewUnauthorizedError("invalid or expired challenge token")
+	}
+	account, err := userService.FindByID(claims.UserID)
+	if err != nil {
+		return exceptions.NewUnauthorizedError("invalid or expired challenge token")
+	}
+
+	ip := utils.GetClientIP(r)
+	if err := lockoutService.Check(account.Email, ip); err != nil {
+		return err
+	}
+
+	user, tokens, err := userService.VerifyTwoFactor(req.Challenge_token, req.Code, deviceFromRequest(r))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.UNAUTHORIZED {
+			if err := lockoutService.RegisterFailure(account.Email, ip); err != nil {
+				log.Printf("error registering failed two-factor login for %s: %v", account.Email, err)
+			}
+		}
+		return err
+	}
+
+	if err := lockoutService.RegisterSuccess(user.Email); err != nil {
+		log.Printf("error clearing failed logins for %s: %v", user.Email, err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, loginResponse(user, tokens))
+	return nil
+}
+
+func setupTwoFactor(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to set up two-factor authentication for user %d", userID)
+
+	setup, err := userService.SetupTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, setup)
+	return nil
+}
+
+func enableTwoFactor(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	log.Printf("-> new request to enable two-factor authentication for user %d", claims.UserID)
+	var req types.TwoFactorCodeRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	codes, tokens, err := userService.EnableTwoFactor(*claims, req.Code, deviceFromRequest(r))
+	if err != nil {
+		return err
+	}
+
+	response := map[string]interface{}{
+		"recovery_codes": codes,
+		"message":        "Two-factor authentication enabled. Store the recovery codes somewhere safe, they won't be shown again",
+	}
+
+	// Enrolled during login, so the login is complete
+	if tokens.Token != "" {
+		user, err := userService.FindByID(claims.UserID)
+		if err != nil {
+			return err
+		}
+		for key, value := range loginResponse(user, tokens) {
+			response[key] = value
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, response)
+	return nil
+}
+
+func disableTwoFactor(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to disable two-factor authentication for user %d", userID)
+	var req types.TwoFactorDisableRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	if err := userService.DisableTwoFactor(userID, req.Password, req.Code); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Two-factor authentication disabled",
+	})
+	return nil
+}
+
+func regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to regenerate recovery codes for user %d", userID)
+	var req types.TwoFactorCodeRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	codes, err := userService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+	return nil
+}
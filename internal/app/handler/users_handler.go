@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/domain/lockout"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/middleware"
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var avatarMimeTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// UsersHandler serves the profile of the logged in user.
+func UsersHandler(userService user.UserService, lockoutService lockout.LockoutService, authenticator *auth.Authenticator) http.HandlerFunc {
+	router := newRouter()
+
+	withUser := func(h middleware.HandlerFunc) http.HandlerFunc {
+		return utils.Compose(
+			middleware.ErrorHandlerFunc(h),
+			middleware.ErrorHandler,
+			authenticator.WithJwtAuth,
+		)
+	}
+
+	router.HandleFunc("/api/users/me", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return getProfile(w, r, userService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/users/me", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return updateProfile(w, r, userService)
+	})).Methods(http.MethodPatch)
+
+	router.HandleFunc("/api/users/me/password", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return changePassword(w, r, userService)
+	})).Methods(http.MethodPut)
+
+	router.HandleFunc("/api/users/me/avatar", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return updateAvatar(w, r, userService)
+	})).Methods(http.MethodPut)
+
+	router.HandleFunc("/api/users/me/data-export", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return exportPersonalData(w, r, userService)
+	})).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/users/me/erasure", withUser(func(w http.ResponseWriter, r *http.Request) error {
+		return erasePersonalData(w, r, userService, lockoutService)
+	})).Methods(http.MethodPost)
+
+	return router.ServeHTTP
+}
+
+func getProfile(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to get profile of user %d", userID)
+
+	user, err := userService.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJsonWithETag(w, r, http.StatusOK, user, utils.VersionETag(user.Version))
+	return nil
+}
+
+func updateProfile(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to update profile of user %d", userID)
+
+	current, err := userService.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckIfMatch(r, "user", utils.VersionETag(current.Version)); err != nil {
+		return err
+	}
+
+	patch := user.UserToProfilePatch(current)
+	if err := utils.ParseAndApplyMergePatch(r, &patch); err != nil {
+		return err
+	}
+
+	updated, err := userService.UpdateProfile(user.ApplyProfilePatch(current, patch))
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJsonWithETag(w, r, http.StatusOK, updated, utils.VersionETag(updated.Version))
+	return nil
+}
+
+func changePassword(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	claims, _ := auth.GetClaimsFromContext(r.Context())
+	log.Printf("-> new request to change password of user %d", claims.UserID)
+	var req types.ChangePasswordRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	if err := userService.ChangePassword(claims.UserID, req.Current_password, req.Password, claims.SessionID); err != nil {
+		return err
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Password changed successfully",
+	})
+	return nil
+}
+
+func updateAvatar(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to update avatar of user %d", userID)
+
+	maxBytes := int64(config.Envs.AVATAR_MAX_BYTES)
+	// Leaves room for the multipart boundaries and headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return exceptions.NewValidationError("avatar", fmt.Sprintf("The avatar must be at most %d KB", maxBytes>>10))
+		}
+		return exceptions.NewValidationError("avatar", "The field avatar is required")
+	}
+	defer file.Close()
+
+	var content bytes.Buffer
+	if _, err := io.Copy(&content, io.LimitReader(file, maxBytes+1)); err != nil {
+		return exceptions.NewValidationError("avatar", "The avatar could not be read")
+	}
+	if int64(content.Len()) > maxBytes {
+		return exceptions.NewValidationError("avatar", fmt.Sprintf("The avatar must be at most %d KB", maxBytes>>10))
+	}
+
+	// The declared content type is ignored, only the actual bytes count
+	if !mimetype.EqualsAny(mimetype.Detect(content.Bytes()).String(), avatarMimeTypes...) {
+		return exceptions.NewValidationError("avatar", "The avatar must be a JPEG, PNG or GIF image")
+	}
+
+	updated, err := userService.UpdateAvatar(userID, content.Bytes())
+	if err != nil {
+		return err
+	}
+
+	utils.WriteJsonWithETag(w, r, http.StatusOK, updated, utils.VersionETag(updated.Version))
+	return nil
+}
+
+func exportPersonalData(w http.ResponseWriter, r *http.Request, userService user.UserService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to export personal data of user %d", userID)
+
+	export, err := userService.ExportPersonalData(userID, deviceFromRequest(r))
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("personal-data-%d-%s.json", userID, export.Generated_at.Format("20060102"))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJson(w, http.StatusOK, export)
+	return nil
+}
+
+func erasePersonalData(w http.ResponseWriter, r *http.Request, userService user.UserService, lockoutService lockout.LockoutService) error {
+	userID := auth.GetUserIDFromContext(r.Context())
+	log.Printf("-> new request to erase personal data of user %d", userID)
+	var req types.EraseDataRequest
+
+	if err := utils.ParseAndValidateJson(r, &req); err != nil {
+		return err
+	}
+
+	// The failed logins are keyed by the email the erasure replaces
+	current, err := userService.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := userService.ErasePersonalData(userID, req.Password, req.Code, deviceFromRequest(r)); err != nil {
+		return err
+	}
+
+	if err := lockoutService.RegisterSuccess(current.Email); err != nil {
+		log.Printf("error clearing failed logins of user %d: %v", userID, err)
+	}
+
+	utils.WriteJson(w, http.StatusOK, map[string]interface{}{
+		"message": "Your personal data was erased and your account closed",
+	})
+	return nil
+}
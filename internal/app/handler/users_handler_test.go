@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-restaurant-management/internal/domain/user"
+	"go-restaurant-management/internal/shared/auth"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUsersHandler(t *testing.T) {
+	token, err := auth.CreateJWT(auth.Claims{UserID: 7, Role: user.RoleWaiter, SessionID: "tablet"}, auth.AccessToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(h http.HandlerFunc, method string, path string, body *bytes.Buffer, header map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	avatarForm := func(t *testing.T, content []byte) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("avatar", "avatar.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+		writer.Close()
+		return body, writer.FormDataContentType()
+	}
+
+	t.Run("should only change the fields sent on profile update", func(t *testing.T) {
+		var saved user.User
+		mockUserService := &MockUserService{
+			FindByIDFunc: func(id int) (user.User, error) {
+				return user.User{ID: id, First_name: "Ana", Last_name: "Souza", Phone: "+5511987654321", Version: 3}, nil
+			},
+			UpdateProfileFunc: func(u user.User) (user.User, error) {
+				saved = u
+				return u, nil
+			},
+		}
+
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PATCH", "/api/users/me", bytes.NewBufferString(`{"last_name": "Lima"}`), map[string]string{"Content-Type": "application/json", "If-Match": `"v3"`})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if saved.First_name != "Ana" || saved.Last_name != "Lima" || saved.Phone != "+5511987654321" {
+			t.Errorf("unexpected profile saved: %+v", saved)
+		}
+	})
+
+	t.Run("should refuse fields the profile update can't change", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			FindByIDFunc: func(id int) (user.User, error) {
+				return user.User{ID: id, First_name: "Ana", Last_name: "Souza", Phone: "+5511987654321", Version: 3}, nil
+			},
+			UpdateProfileFunc: func(u user.User) (user.User, error) {
+				t.Error("expected the service not to be called")
+				return u, nil
+			},
+		}
+
+		for _, body := range []string{`{"email": "ana@example.com"}`, `{"role": "admin"}`, `{"phone": null}`} {
+			rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PATCH", "/api/users/me", bytes.NewBufferString(body), map[string]string{"Content-Type": "application/json", "If-Match": `"v3"`})
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 for %s, got %v: %s", body, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("should check the ETag on profile update", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			FindByIDFunc: func(id int) (user.User, error) {
+				return user.User{ID: id, Version: 4}, nil
+			},
+			UpdateProfileFunc: func(u user.User) (user.User, error) {
+				t.Error("expected the service not to be called")
+				return u, nil
+			},
+		}
+
+		tests := []struct {
+			name       string
+			ifMatch    string
+			wantStatus int
+		}{
+			{"should require If-Match", "", http.StatusPreconditionRequired},
+			{"should reject an outdated version", `"v3"`, http.StatusPreconditionFailed},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				header := map[string]string{"Content-Type": "application/json"}
+				if tt.ifMatch != "" {
+					header["If-Match"] = tt.ifMatch
+				}
+
+				rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PATCH", "/api/users/me", bytes.NewBufferString(`{"last_name": "Lima"}`), header)
+				if rr.Code != tt.wantStatus {
+					t.Errorf("expected %d, got %v: %s", tt.wantStatus, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("should answer 304 when the profile didn't change", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			FindByIDFunc: func(id int) (user.User, error) {
+				return user.User{ID: id, Version: 4}, nil
+			},
+		}
+
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "GET", "/api/users/me", &bytes.Buffer{}, map[string]string{"If-None-Match": `"v4"`})
+		if rr.Code != http.StatusNotModified {
+			t.Fatalf("expected 304, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if rr.Header().Get("ETag") != `"v4"` || rr.Body.Len() != 0 {
+			t.Errorf("expected the ETag without a body, got %q and %q", rr.Header().Get("ETag"), rr.Body.String())
+		}
+	})
+
+	t.Run("should keep the current session on password change", func(t *testing.T) {
+		var keptSession string
+		mockUserService := &MockUserService{
+			ChangePasswordFunc: func(userID int, currentPassword string, password string, sessionID string) error {
+				keptSession = sessionID
+				return nil
+			},
+		}
+
+		body := bytes.NewBufferString(`{"current_password": "Bistro#Night42", "password": "Quiet-Harbor-Lamp-19"}`)
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PUT", "/api/users/me/password", body, map[string]string{"Content-Type": "application/json"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if keptSession != "tablet" {
+			t.Errorf("expected session tablet to be kept, got %q", keptSession)
+		}
+	})
+
+	t.Run("should accept a PNG avatar", func(t *testing.T) {
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+			t.Fatal(err)
+		}
+
+		var received []byte
+		mockUserService := &MockUserService{
+			UpdateAvatarFunc: func(userID int, content []byte) (user.User, error) {
+				received = content
+				return user.User{ID: userID, Avatar: "/uploads/avatars/7-1.png", Version: 5}, nil
+			},
+		}
+
+		body, contentType := avatarForm(t, encoded.Bytes())
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PUT", "/api/users/me/avatar", body, map[string]string{"Content-Type": contentType})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if !bytes.Equal(received, encoded.Bytes()) {
+			t.Error("expected the uploaded bytes to reach the service")
+		}
+
+		var response user.User
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.Avatar != "/uploads/avatars/7-1.png" {
+			t.Errorf("unexpected avatar %q", response.Avatar)
+		}
+		if rr.Header().Get("ETag") != `"v5"` {
+			t.Errorf("expected the ETag of the new version, got %q", rr.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("should reject an avatar that is not an image", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			UpdateAvatarFunc: func(userID int, content []byte) (user.User, error) {
+				t.Error("expected the service not to be called")
+				return user.User{}, nil
+			},
+		}
+
+		body, contentType := avatarForm(t, []byte("<svg onload=alert(1)></svg>"))
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "PUT", "/api/users/me/avatar", body, map[string]string{"Content-Type": contentType})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %v: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("should download the personal data export", func(t *testing.T) {
+		mockUserService := &MockUserService{
+			ExportPersonalDataFunc: func(userID int, device user.Device) (user.PersonalDataExport, error) {
+				return user.PersonalDataExport{
+					Generated_at: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+					Profile:      user.User{ID: userID, Email: "ana@example.com"},
+				}, nil
+			},
+		}
+
+		rr := request(UsersHandler(mockUserService, newTestLockoutService(), newTestAuthenticator(mockUserService)), "GET", "/api/users/me/data-export", &bytes.Buffer{}, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="personal-data-7-20261019.json"` {
+			t.Errorf("unexpected Content-Disposition %q", got)
+		}
+
+		var export user.PersonalDataExport
+		json.NewDecoder(rr.Body).Decode(&export)
+		if export.Profile.Email != "ana@example.com" {
+			t.Errorf("unexpected profile in export: %+v", export.Profile)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/app/migrations"
+	"log"
+	"os"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// errNoSuchTable is the MySQL error of queries on a missing table.
+const errNoSuchTable = 1146
+
+// NewMigrationSource reads the migrations embedded in the binary.
+func NewMigrationSource() (source.Driver, error) {
+	return iofs.New(migrations.FS, ".")
+}
+
+// NewMigrate applies the embedded migrations to db, which must have its
+// database selected and allow multiple statements. It runs on a connection of its own, closing it returns
+// that connection to db and leaves db open.
+func NewMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	ctx := context.Background()
+	src, err := NewMigrationSource()
+	if err != nil {
+		return nil, err
+	}
+
+	// WithInstance would close db along with the migrator
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	driver, err := mysql.WithConnection(ctx, conn, &mysql.Config{})
+	if err != nil {
+		conn.Close()
+		src.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "mysql", driver)
+	if err != nil {
+		driver.Close()
+		src.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// MigrationVersions returns the versions of src in ascending order.
+func MigrationVersions(src source.Driver) ([]uint, error) {
+	var versions []uint
+	version, err := src.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// PrepareSchema checks that the API can run on the database schema. With
+// autoMigrate the pending migrations are applied first, holding a lock so
+// only one instance migrates while the others wait up to lockTimeout.
+func PrepareSchema(db *sql.DB, autoMigrate bool, lockTimeout time.Duration) error {
+	src, err := NewMigrationSource()
+	if err != nil {
+		return err
+	}
+	versions, err := MigrationVersions(src)
+	src.Close()
+	if err != nil {
+		return err
+	}
+	latest := -1
+	if len(versions) > 0 {
+		latest = int(versions[len(versions)-1])
+	}
+
+	if !autoMigrate {
+		return checkSchema(db, latest)
+	}
+
+	return withMigrationLock(db, lockTimeout, func() error {
+		// Migrating a dirty or newer schema would fail or downgrade it
+		if err := checkSchema(db, latest); err != nil {
+			return err
+		}
+
+		m, err := NewMigrate(db)
+		if err != nil {
+			return err
+		}
+		defer m.Close()
+
+		log.Println("DB: Applying pending migrations")
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("applying migrations: %w", err)
+		}
+		return checkSchema(db, latest)
+	})
+}
+
+func checkSchema(db *sql.DB, latest int) error {
+	version, dirty, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	return schemaStatus(version, dirty, latest)
+}
+
+// schemaVersion reads the version recorded by the migrations, -1 when none
+// was applied. Unlike the migrator it doesn't create the version table.
+func schemaVersion(db *sql.DB) (int, bool, error) {
+	var version int
+	var dirty bool
+	err := db.QueryRow("SELECT version, dirty FROM `"+mysql.DefaultMigrationsTable+"` LIMIT 1").Scan(&version, &dirty)
+
+	var mysqlErr *gomysql.MySQLError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return -1, false, nil
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == errNoSuchTable:
+		return -1, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// schemaStatus refuses dirty schemas and schemas newer than the latest
+// migration of this build, whose code may not work with them. Pending
+// migrations are only reported, they can be applied after the deploy.
+func schemaStatus(version int, dirty bool, latest int) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("database schema is dirty at version %d, fix it and run migrate force", version)
+	case version > latest:
+		return fmt.Errorf("database schema is at version %d, newer than the latest migration %d of this build", version, latest)
+	case version < latest:
+		log.Printf("DB: Schema is at version %d, migrations up to %d are pending", version, latest)
+	default:
+		log.Printf("DB: Schema is at version %d", version)
+	}
+	return nil
+}
+
+// withMigrationLock runs fn holding a MySQL named lock, which belongs to the
+// connection and is released if the instance dies.
+func withMigrationLock(db *sql.DB, timeout time.Duration, fn func() error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Lock names are limited to 64 characters
+	name := "migrate:" + config.Envs.DB_NAME
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(timeout.Seconds())).Scan(&acquired); err != nil {
+		return err
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("timed out after %s waiting for another instance to finish migrating", timeout)
+	}
+	defer func() {
+		var released sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", name).Scan(&released); err != nil {
+			log.Printf("DB: Error releasing migration lock: %v", err)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		dirty   bool
+		wantErr bool
+	}{
+		{"should accept the latest version", 20261019101300, false, false},
+		{"should accept pending migrations", 20261019100000, false, false},
+		{"should accept an empty database", -1, false, false},
+		{"should refuse a dirty schema", 20261019101300, true, true},
+		{"should refuse a schema newer than the build", 20261019101400, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schemaStatus(tt.version, tt.dirty, 20261019101300)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("schemaStatus(%d, %v) error = %v, wantErr %v", tt.version, tt.dirty, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrationVersions(t *testing.T) {
+	t.Run("should list the embedded migrations in order", func(t *testing.T) {
+		src, err := NewMigrationSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer src.Close()
+
+		versions, err := MigrationVersions(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) == 0 || versions[0] != 20250808032626 {
+			t.Fatalf("expected the users table to come first, got %v", versions)
+		}
+		for i := 1; i < len(versions); i++ {
+			if versions[i] <= versions[i-1] {
+				t.Errorf("versions out of order: %d after %d", versions[i], versions[i-1])
+			}
+		}
+	})
+}
+
+func TestPrepareSchema(t *testing.T) {
+	for _, autoMigrate := range []bool{false, true} {
+		t.Run(fmt.Sprintf("should leave the pool open with autoMigrate %v", autoMigrate), func(t *testing.T) {
+			db := sql.OpenDB(fakeConnector{})
+			defer db.Close()
+
+			if err := PrepareSchema(db, autoMigrate, time.Second); err != nil {
+				t.Fatalf("PrepareSchema() error = %v", err)
+			}
+
+			var name string
+			if err := db.QueryRow("SELECT DATABASE()").Scan(&name); err != nil {
+				t.Fatalf("the pool isn't usable after PrepareSchema: %v", err)
+			}
+		})
+	}
+}
+
+// fakeConnector is a database at the latest migration, answering the queries
+// PrepareSchema and the migrator send.
+type fakeConnector struct{}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.HasPrefix(query, "SELECT DATABASE()"):
+		return &fakeRows{columns: []string{"name"}, values: []driver.Value{"restaurant"}}, nil
+	case strings.HasPrefix(query, "SELECT GET_LOCK"), strings.HasPrefix(query, "SELECT RELEASE_LOCK"):
+		return &fakeRows{columns: []string{"result"}, values: []driver.Value{int64(1)}}, nil
+	case strings.HasPrefix(query, "SHOW TABLES"):
+		return &fakeRows{columns: []string{"table"}, values: []driver.Value{"schema_migrations"}}, nil
+	case strings.HasPrefix(query, "SELECT version, dirty"):
+		src, err := NewMigrationSource()
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		versions, err := MigrationVersions(src)
+		if err != nil {
+			return nil, err
+		}
+		return &fakeRows{columns: []string{"version", "dirty"}, values: []driver.Value{int64(versions[len(versions)-1]), false}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+// fakeRows has a single row.
+type fakeRows struct {
+	columns []string
+	values  []driver.Value
+	read    bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	copy(dest, r.values)
+	return nil
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key VARCHAR(255) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NULL,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMP NULL AFTER role;
+UPDATE users SET verified_at = created_at WHERE verified_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64) NULL AFTER verified_at,
+    ADD COLUMN totp_enabled_at TIMESTAMP NULL AFTER totp_secret,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0 AFTER totp_enabled_at;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN pin_hash;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pos_terminals;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP NULL AFTER verified_at;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMP NULL AFTER deactivated_at;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_data_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_user_data_requests_user (user_id),
+    CONSTRAINT fk_user_data_requests_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
\ No newline at end of file
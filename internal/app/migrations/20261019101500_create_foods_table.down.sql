@@ -0,0 +1 @@
+DROP TABLE IF EXISTS foods;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dining_tables;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS orders;
\ No newline at end of file
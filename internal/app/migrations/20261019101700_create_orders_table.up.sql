@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    reference VARCHAR(50) NOT NULL UNIQUE,
+    order_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    table_id INT NOT NULL,
+    waiter_id INT NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_orders_table (table_id),
+    INDEX idx_orders_waiter (waiter_id),
+    INDEX idx_orders_order_date (order_date),
+    CONSTRAINT fk_orders_table FOREIGN KEY (table_id) REFERENCES dining_tables (id),
+    CONSTRAINT fk_orders_waiter FOREIGN KEY (waiter_id) REFERENCES users (id)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL UNIQUE,
+    payment_method VARCHAR(10) NOT NULL,
+    payment_status VARCHAR(10) NOT NULL DEFAULT 'PENDING',
+    payment_due_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tax_id VARCHAR(18) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_invoices_order FOREIGN KEY (order_id) REFERENCES orders (id)
+);
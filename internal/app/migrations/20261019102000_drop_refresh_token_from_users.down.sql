@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN refresh_token VARCHAR(255) AFTER token;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN refresh_token;
\ No newline at end of file
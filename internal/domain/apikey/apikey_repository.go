@@ -0,0 +1,134 @@
+package apikey
+
+import (
+	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+type ApiKeyRepository interface {
+	Save(key ApiKey) (ApiKey, error)
+	FindByID(id int) (ApiKey, error)
+	// FindActiveByPrefix ignores revoked keys.
+	FindActiveByPrefix(prefix string) (ApiKey, error)
+	FindAll() ([]ApiKey, error)
+	Revoke(id int) error
+	Touch(id int, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	*sql.DB
+}
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, allowed_ips, created_by, last_used_at, revoked_at, created_at"
+
+func scanApiKey(scan func(dest ...any) error) (ApiKey, error) {
+	var key ApiKey
+	var scopes, allowedIPs string
+	var createdBy sql.NullInt64
+	var lastUsedAt, revokedAt sql.NullTime
+	err := scan(&key.ID, &key.Name, &key.Prefix, &key.Key_hash, &scopes, &allowedIPs, &createdBy, &lastUsedAt, &revokedAt, &key.CreatedAt)
+
+	key.Scopes = splitList(scopes)
+	key.Allowed_ips = splitList(allowedIPs)
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.Created_by = &id
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, err
+}
+
+func (a *apiKeyRepository) Save(key ApiKey) (ApiKey, error) {
+	log.Printf("saving api key %s to database", key.Name)
+	query := "INSERT INTO api_keys (name, prefix, key_hash, scopes, allowed_ips, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := a.DB.Exec(query, key.Name, key.Prefix, key.Key_hash, joinList(key.Scopes), joinList(key.Allowed_ips), key.Created_by, key.CreatedAt)
+	if err != nil {
+		log.Printf("error saving api key %s: %v", key.Name, err)
+		return ApiKey{}, exceptions.FromDatabaseError(err, "api key")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return ApiKey{}, exceptions.FromDatabaseError(err, "api key")
+	}
+
+	key.ID = int(id)
+	return key, nil
+}
+
+func (a *apiKeyRepository) FindByID(id int) (ApiKey, error) {
+	key, err := scanApiKey(a.DB.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ApiKey{}, exceptions.NewEntityNotFound("api key", id)
+		}
+		log.Printf("error finding api key %d: %v", id, err)
+		return ApiKey{}, exceptions.FromDatabaseError(err, "api key")
+	}
+	return key, nil
+}
+
+func (a *apiKeyRepository) FindActiveByPrefix(prefix string) (ApiKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = ? AND revoked_at IS NULL"
+
+	key, err := scanApiKey(a.DB.QueryRow(query, prefix).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ApiKey{}, exceptions.NewEntityNotFound("api key", prefix)
+		}
+		log.Printf("error finding api key %s: %v", prefix, err)
+		return ApiKey{}, exceptions.FromDatabaseError(err, "api key")
+	}
+	return key, nil
+}
+
+func (a *apiKeyRepository) FindAll() ([]ApiKey, error) {
+	rows, err := a.DB.Query("SELECT " + apiKeyColumns + " FROM api_keys ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		log.Printf("error listing api keys: %v", err)
+		return nil, exceptions.FromDatabaseError(err, "api key")
+	}
+	defer rows.Close()
+
+	keys := []ApiKey{}
+	for rows.Next() {
+		key, err := scanApiKey(rows.Scan)
+		if err != nil {
+			return nil, exceptions.FromDatabaseError(err, "api key")
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, exceptions.FromDatabaseError(err, "api key")
+	}
+
+	return keys, nil
+}
+
+func (a *apiKeyRepository) Revoke(id int) error {
+	if _, err := a.DB.Exec("UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id); err != nil {
+		log.Printf("error revoking api key %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "api key")
+	}
+	return nil
+}
+
+func (a *apiKeyRepository) Touch(id int, usedAt time.Time) error {
+	if _, err := a.DB.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", usedAt, id); err != nil {
+		return exceptions.FromDatabaseError(err, "api key")
+	}
+	return nil
+}
+
+func NewApiKeyRepository(db *sql.DB) ApiKeyRepository {
+	return &apiKeyRepository{db}
+}
@@ -0,0 +1,146 @@
+package apikey
+
+import (
+	"crypto/subtle"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Last use is only recorded once per interval, not on every request
+const touchInterval = time.Minute
+
+type ApiKeyService interface {
+	// Create returns the key and its plain value, which is only shown once.
+	Create(name string, scopes []string, allowedIPs []string, createdBy int) (ApiKey, string, error)
+	FindAll() ([]ApiKey, error)
+	Revoke(id int) error
+	// Authenticate returns the active key, if it may be used from ip.
+	Authenticate(key string, ip string) (ApiKey, error)
+	// Identify returns the prefix of an active key, without checking the address.
+	Identify(key string) (string, bool)
+	// AuthenticateAPIKey is Authenticate for the auth middlewares, which only
+	// need the claims of the key.
+	AuthenticateAPIKey(key string, ip string) (*auth.Claims, error)
+}
+
+type apiKeyService struct {
+	ApiKeyRepository
+}
+
+func (a *apiKeyService) Create(name string, scopes []string, allowedIPs []string, createdBy int) (ApiKey, string, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return ApiKey{}, "", exceptions.NewValidationError("scopes", "Unknown scope "+scope+", expected one of "+strings.Join(Scopes, ", "))
+		}
+	}
+
+	for i, allowed := range allowedIPs {
+		allowed = strings.TrimSpace(allowed)
+		if _, _, err := net.ParseCIDR(allowed); err != nil && net.ParseIP(allowed) == nil {
+			return ApiKey{}, "", exceptions.NewValidationError("allowed_ips", allowed+" is not a valid IP address or CIDR")
+		}
+		allowedIPs[i] = allowed
+	}
+
+	plain, prefix, err := newKey()
+	if err != nil {
+		return ApiKey{}, "", exceptions.NewInternalServerError(err.Error())
+	}
+
+	key, err := a.ApiKeyRepository.Save(ApiKey{
+		Name:        name,
+		Prefix:      prefix,
+		Key_hash:    hashKey(plain),
+		Scopes:      slices.Compact(slices.Sorted(slices.Values(scopes))),
+		Allowed_ips: allowedIPs,
+		Created_by:  &createdBy,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return ApiKey{}, "", err
+	}
+
+	log.Printf("api key %s (%s) created by user %d", key.Name, key.Prefix, createdBy)
+	return key, plain, nil
+}
+
+func (a *apiKeyService) FindAll() ([]ApiKey, error) {
+	return a.ApiKeyRepository.FindAll()
+}
+
+func (a *apiKeyService) Revoke(id int) error {
+	if _, err := a.ApiKeyRepository.FindByID(id); err != nil {
+		return err
+	}
+
+	log.Printf("revoking api key %d", id)
+	return a.ApiKeyRepository.Revoke(id)
+}
+
+func (a *apiKeyService) Authenticate(plain string, ip string) (ApiKey, error) {
+	key, err := a.find(plain)
+	if err != nil {
+		return ApiKey{}, err
+	}
+
+	if !key.AllowsIP(ip) {
+		log.Printf("api key %s used from unexpected address %s", key.Prefix, ip)
+		return ApiKey{}, exceptions.NewForbiddenError("api key is not allowed from this address")
+	}
+
+	if now := time.Now(); key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		if err := a.ApiKeyRepository.Touch(key.ID, now); err != nil {
+			log.Printf("error updating last use of api key %s: %v", key.Prefix, err)
+		}
+	}
+
+	return key, nil
+}
+
+func (a *apiKeyService) AuthenticateAPIKey(plain string, ip string) (*auth.Claims, error) {
+	key, err := a.Authenticate(plain, ip)
+	if err != nil {
+		return nil, err
+	}
+	claims := key.Claims()
+	return &claims, nil
+}
+
+func (a *apiKeyService) Identify(plain string) (string, bool) {
+	key, err := a.find(plain)
+	if err != nil {
+		return "", false
+	}
+	return key.Prefix, true
+}
+
+func (a *apiKeyService) find(plain string) (ApiKey, error) {
+	prefix, ok := parsePrefix(plain)
+	if !ok {
+		return ApiKey{}, exceptions.NewUnauthorizedError("invalid api key")
+	}
+
+	key, err := a.ApiKeyRepository.FindActiveByPrefix(prefix)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			return ApiKey{}, exceptions.NewUnauthorizedError("invalid api key")
+		}
+		return ApiKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Key_hash), []byte(hashKey(plain))) != 1 {
+		return ApiKey{}, exceptions.NewUnauthorizedError("invalid api key")
+	}
+
+	return key, nil
+}
+
+func NewApiKeyService(apiKeyRepository ApiKeyRepository) ApiKeyService {
+	return &apiKeyService{apiKeyRepository}
+}
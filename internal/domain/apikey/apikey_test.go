@@ -0,0 +1,46 @@
+package apikey
+
+import "testing"
+
+func TestApiKey(t *testing.T) {
+	t.Run("should generate keys whose prefix can be parsed back", func(t *testing.T) {
+		key, prefix, err := newKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, ok := parsePrefix(key)
+		if !ok || parsed != prefix {
+			t.Errorf("expected prefix %s from %s, got %s", prefix, key, parsed)
+		}
+
+		for _, invalid := range []string{"", "rk_", "rk_abc_secret", "sk_0123abcd_secret", "rk_0123abcd_"} {
+			if _, ok := parsePrefix(invalid); ok {
+				t.Errorf("expected %q to be rejected", invalid)
+			}
+		}
+	})
+
+	t.Run("should restrict keys to the allowed addresses", func(t *testing.T) {
+		tests := []struct {
+			allowed []string
+			ip      string
+			want    bool
+		}{
+			{nil, "198.51.100.7", true},
+			{[]string{"198.51.100.7"}, "198.51.100.7", true},
+			{[]string{"198.51.100.7"}, "198.51.100.8", false},
+			{[]string{"203.0.113.0/24"}, "203.0.113.42", true},
+			{[]string{"203.0.113.0/24"}, "203.0.114.1", false},
+			{[]string{"2001:db8::/32"}, "2001:db8::1", true},
+			{[]string{"203.0.113.0/24"}, "not an ip", false},
+		}
+
+		for _, tt := range tests {
+			key := ApiKey{Allowed_ips: tt.allowed}
+			if got := key.AllowsIP(tt.ip); got != tt.want {
+				t.Errorf("AllowsIP(%s) with %v = %v, want %v", tt.ip, tt.allowed, got, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package idempotency
+
+import (
+	"net/http"
+	"time"
+)
+
+// Record is the first response sent for an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string
+	// Status is 0 while the first request is still running
+	Status    int
+	Header    http.Header
+	Body      []byte
+	ExpiresAt time.Time
+}
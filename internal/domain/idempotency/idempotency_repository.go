@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRepository keeps the records of idempotency keys per client scope,
+// e.g. "user:12".
+type IdempotencyRepository interface {
+	// Reserve claims key until expiresAt. When the key is taken by a record
+	// that hasn't expired, that record is returned with false instead.
+	Reserve(scope string, key string, fingerprint string, now time.Time, expiresAt time.Time) (Record, bool, error)
+	// Complete saves the response of a reserved key.
+	Complete(scope string, key string, record Record) error
+	// Release frees a reserved key that has no response yet.
+	Release(scope string, key string) error
+	DeleteExpired(now time.Time) error
+}
+
+type idempotencyRepository struct {
+	*sql.DB
+}
+
+func (i *idempotencyRepository) Reserve(scope string, key string, fingerprint string, now time.Time, expiresAt time.Time) (Record, bool, error) {
+	// An expired record is taken over; expires_at is assigned last, so the
+	// IFs before it still see the previous expiration
+	query := `INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, created_at, expires_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			fingerprint = IF(expires_at < VALUES(created_at), VALUES(fingerprint), fingerprint),
+			status_code = IF(expires_at < VALUES(created_at), NULL, status_code),
+			headers = IF(expires_at < VALUES(created_at), NULL, headers),
+			body = IF(expires_at < VALUES(created_at), NULL, body),
+			created_at = IF(expires_at < VALUES(created_at), VALUES(created_at), created_at),
+			expires_at = IF(expires_at < VALUES(created_at), VALUES(expires_at), expires_at)`
+
+	// The record can be released between both statements, then the key is
+	// reserved again
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := i.DB.Exec(query, scope, key, fingerprint, now, expiresAt)
+		if err != nil {
+			log.Printf("error reserving idempotency key of %s: %v", scope, err)
+			return Record{}, false, exceptions.FromDatabaseError(err, "idempotency key")
+		}
+
+		// 1 for a new row, 2 for a taken over one and 0 when the row was kept
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return Record{}, false, exceptions.FromDatabaseError(err, "idempotency key")
+		}
+		if affected > 0 {
+			return Record{}, true, nil
+		}
+
+		record, err := i.find(scope, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			log.Printf("error finding idempotency key of %s: %v", scope, err)
+			return Record{}, false, exceptions.FromDatabaseError(err, "idempotency key")
+		}
+		return record, false, nil
+	}
+
+	return Record{}, false, exceptions.NewInternalServerError("idempotency key could not be reserved")
+}
+
+// find returns sql.ErrNoRows when there is no record.
+func (i *idempotencyRepository) find(scope string, key string) (Record, error) {
+	query := "SELECT fingerprint, COALESCE(status_code, 0), COALESCE(headers, ''), body, expires_at FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?"
+
+	var record Record
+	var headers string
+	if err := i.DB.QueryRow(query, scope, key).Scan(&record.Fingerprint, &record.Status, &headers, &record.Body, &record.ExpiresAt); err != nil {
+		return Record{}, err
+	}
+
+	if headers != "" {
+		record.Header = http.Header{}
+		if err := json.Unmarshal([]byte(headers), &record.Header); err != nil {
+			return Record{}, err
+		}
+	}
+	return record, nil
+}
+
+func (i *idempotencyRepository) Complete(scope string, key string, record Record) error {
+	headers, err := json.Marshal(record.Header)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	query := "UPDATE idempotency_keys SET status_code = ?, headers = ?, body = ?, expires_at = ? WHERE scope = ? AND idempotency_key = ? AND fingerprint = ?"
+	if _, err := i.DB.Exec(query, record.Status, string(headers), record.Body, record.ExpiresAt, scope, key, record.Fingerprint); err != nil {
+		log.Printf("error saving the response of an idempotency key of %s: %v", scope, err)
+		return exceptions.FromDatabaseError(err, "idempotency key")
+	}
+	return nil
+}
+
+func (i *idempotencyRepository) Release(scope string, key string) error {
+	if _, err := i.DB.Exec("DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND status_code IS NULL", scope, key); err != nil {
+		log.Printf("error releasing idempotency key of %s: %v", scope, err)
+		return exceptions.FromDatabaseError(err, "idempotency key")
+	}
+	return nil
+}
+
+func (i *idempotencyRepository) DeleteExpired(now time.Time) error {
+	result, err := i.DB.Exec("DELETE FROM idempotency_keys WHERE expires_at < ?", now)
+	if err != nil {
+		log.Printf("error deleting expired idempotency keys: %v", err)
+		return exceptions.FromDatabaseError(err, "idempotency key")
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		log.Printf("deleted %d expired idempotency keys", deleted)
+	}
+	return nil
+}
+
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db}
+}
@@ -0,0 +1,47 @@
+package lockout
+
+import "time"
+
+// Attempt tracks consecutive failed logins for a key, which is either an
+// account ("account:<email>") or a client address ("ip:<address>"), prefixed
+// by the policy scope if it has one.
+type Attempt struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+type Policy struct {
+	// Scope keeps the counters of different login methods apart, e.g. "pin".
+	Scope string
+
+	MaxAccountFailures int
+	MaxIPFailures      int
+	// Failures below the maximum are throttled with a delay that starts at
+	// BaseDelay once DelayAfter failures are reached and doubles each time,
+	// up to MaxDelay.
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// Lockouts start at LockoutDuration and double on each further failure.
+	LockoutDuration time.Duration
+	MaxLockout      time.Duration
+	// Failures older than Window are forgotten.
+	Window time.Duration
+}
+
+func (p Policy) accountKey(email string) string {
+	return p.scoped("account:" + email)
+}
+
+func (p Policy) ipKey(ip string) string {
+	return p.scoped("ip:" + ip)
+}
+
+func (p Policy) scoped(key string) string {
+	if p.Scope == "" {
+		return key
+	}
+	return p.Scope + ":" + key
+}
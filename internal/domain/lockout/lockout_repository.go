@@ -0,0 +1,177 @@
+package lockout
+
+import (
+	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LockoutRepository interface {
+	Find(key string) (Attempt, error)
+	// RegisterFailure increments the failure counter of key, restarting it
+	// when the previous failure happened before windowStart.
+	RegisterFailure(key string, now time.Time, windowStart time.Time) (Attempt, error)
+	Lock(key string, until time.Time) error
+	Delete(key string) error
+	// DeleteExpired deletes the attempts with keys starting with keyPrefix
+	// whose last failure is before failedBefore and that are not locked at now.
+	DeleteExpired(keyPrefix string, failedBefore time.Time, now time.Time) error
+}
+
+type lockoutRepository struct {
+	*sql.DB
+}
+
+func (l *lockoutRepository) Find(key string) (Attempt, error) {
+	query := "SELECT attempt_key, failures, locked_until, last_failure_at FROM login_attempts WHERE attempt_key = ?"
+
+	var attempt Attempt
+	var lockedUntil sql.NullTime
+	err := l.DB.QueryRow(query, key).Scan(&attempt.Key, &attempt.Failures, &lockedUntil, &attempt.LastFailureAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attempt{Key: key}, nil
+	}
+	if err != nil {
+		log.Printf("error finding login attempts for %s: %v", key, err)
+		return Attempt{}, exceptions.FromDatabaseError(err, "login attempt")
+	}
+
+	attempt.LockedUntil = lockedUntil.Time
+	return attempt, nil
+}
+
+func (l *lockoutRepository) RegisterFailure(key string, now time.Time, windowStart time.Time) (Attempt, error) {
+	// failures is assigned before last_failure_at, so the IF still sees the previous failure time
+	query := `INSERT INTO login_attempts (attempt_key, failures, last_failure_at) VALUES (?, 1, ?)
+		ON DUPLICATE KEY UPDATE failures = IF(last_failure_at < ?, 1, failures + 1), last_failure_at = VALUES(last_failure_at)`
+
+	if _, err := l.DB.Exec(query, key, now, windowStart); err != nil {
+		log.Printf("error registering failed login for %s: %v", key, err)
+		return Attempt{}, exceptions.FromDatabaseError(err, "login attempt")
+	}
+
+	return l.Find(key)
+}
+
+func (l *lockoutRepository) Lock(key string, until time.Time) error {
+	if _, err := l.DB.Exec("UPDATE login_attempts SET locked_until = ? WHERE attempt_key = ?", until, key); err != nil {
+		log.Printf("error locking %s: %v", key, err)
+		return exceptions.FromDatabaseError(err, "login attempt")
+	}
+	return nil
+}
+
+func (l *lockoutRepository) Delete(key string) error {
+	if _, err := l.DB.Exec("DELETE FROM login_attempts WHERE attempt_key = ?", key); err != nil {
+		log.Printf("error clearing login attempts for %s: %v", key, err)
+		return exceptions.FromDatabaseError(err, "login attempt")
+	}
+	return nil
+}
+
+func (l *lockoutRepository) DeleteExpired(keyPrefix string, failedBefore time.Time, now time.Time) error {
+	query := `DELETE FROM login_attempts WHERE attempt_key LIKE CONCAT(?, '%') AND last_failure_at < ?
+		AND (locked_until IS NULL OR locked_until < ?)`
+
+	result, err := l.DB.Exec(query, keyPrefix, failedBefore, now)
+	if err != nil {
+		log.Printf("error deleting expired login attempts: %v", err)
+		return exceptions.FromDatabaseError(err, "login attempt")
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		log.Printf("deleted %d expired login attempts", deleted)
+	}
+	return nil
+}
+
+func NewLockoutRepository(db *sql.DB) LockoutRepository {
+	return &lockoutRepository{db}
+}
+
+const memoryEvictionThreshold = 1000
+
+// memoryLockoutRepository keeps attempts in process memory. It is only
+// suitable when a single API instance is running.
+type memoryLockoutRepository struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func (m *memoryLockoutRepository) Find(key string) (Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempt, ok := m.attempts[key]; ok {
+		return attempt, nil
+	}
+	return Attempt{Key: key}, nil
+}
+
+func (m *memoryLockoutRepository) RegisterFailure(key string, now time.Time, windowStart time.Time) (Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(windowStart) {
+		attempt = Attempt{Key: key, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	m.attempts[key] = attempt
+
+	m.evictExpired(windowStart)
+	return attempt, nil
+}
+
+func (m *memoryLockoutRepository) Lock(key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt := m.attempts[key]
+	attempt.Key = key
+	attempt.LockedUntil = until
+	m.attempts[key] = attempt
+	return nil
+}
+
+func (m *memoryLockoutRepository) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *memoryLockoutRepository) DeleteExpired(keyPrefix string, failedBefore time.Time, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, attempt := range m.attempts {
+		if strings.HasPrefix(key, keyPrefix) && attempt.LastFailureAt.Before(failedBefore) && attempt.LockedUntil.Before(now) {
+			delete(m.attempts, key)
+		}
+	}
+	return nil
+}
+
+// evictExpired drops entries that are neither locked nor inside the window,
+// so the map doesn't grow with every address that ever failed a login.
+func (m *memoryLockoutRepository) evictExpired(windowStart time.Time) {
+	if len(m.attempts) < memoryEvictionThreshold {
+		return
+	}
+	for key, attempt := range m.attempts {
+		if attempt.LastFailureAt.Before(windowStart) && attempt.LockedUntil.Before(windowStart) {
+			delete(m.attempts, key)
+		}
+	}
+}
+
+func NewMemoryLockoutRepository() LockoutRepository {
+	return &memoryLockoutRepository{attempts: make(map[string]Attempt)}
+}
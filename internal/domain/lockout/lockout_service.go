@@ -0,0 +1,161 @@
+package lockout
+
+import (
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LockoutService interface {
+	// Check returns a TOO_MANY_REQUESTS error while the account or the
+	// client address is locked.
+	Check(email string, ip string) error
+	RegisterFailure(email string, ip string) error
+	RegisterSuccess(email string) error
+	Unlock(email string) error
+}
+
+type lockoutService struct {
+	LockoutRepository
+	policy Policy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func (l *lockoutService) Check(email string, ip string) error {
+	now := l.now()
+	for _, key := range []string{l.policy.accountKey(normalizeEmail(email)), l.policy.ipKey(ip)} {
+		attempt, err := l.LockoutRepository.Find(key)
+		if err != nil {
+			return err
+		}
+
+		if attempt.LockedUntil.After(now) {
+			log.Printf("login blocked for %s until %s", key, attempt.LockedUntil.Format(time.RFC3339))
+			return exceptions.NewTooManyRequestsError("too many failed login attempts, try again later", attempt.LockedUntil.Sub(now))
+		}
+	}
+
+	return nil
+}
+
+func (l *lockoutService) RegisterFailure(email string, ip string) error {
+	now := l.now()
+	limits := map[string]int{
+		l.policy.accountKey(normalizeEmail(email)): l.policy.MaxAccountFailures,
+		l.policy.ipKey(ip):                         l.policy.MaxIPFailures,
+	}
+
+	l.sweep(now)
+	for key, maxFailures := range limits {
+		attempt, err := l.LockoutRepository.RegisterFailure(key, now, now.Add(-l.policy.Window))
+		if err != nil {
+			return err
+		}
+
+		delay := l.delayFor(attempt.Failures, maxFailures)
+		if delay <= 0 {
+			continue
+		}
+
+		log.Printf("%s has %d failed logins, locking for %s", key, attempt.Failures, delay)
+		if err := l.LockoutRepository.Lock(key, now.Add(delay)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterSuccess clears the account counter. The address counter is left to
+// expire on its own, otherwise logging into one's own account would reset it.
+func (l *lockoutService) RegisterSuccess(email string) error {
+	return l.LockoutRepository.Delete(l.policy.accountKey(normalizeEmail(email)))
+}
+
+func (l *lockoutService) Unlock(email string) error {
+	log.Printf("unlocking account %s", email)
+	return l.LockoutRepository.Delete(l.policy.accountKey(normalizeEmail(email)))
+}
+
+// sweep deletes the attempts of this policy that no longer count at most once
+// an hour, in the background. Failures older than the window restart the
+// counter anyway.
+func (l *lockoutService) sweep(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) < time.Hour {
+		return
+	}
+	l.lastSweep = now
+
+	go func() {
+		for _, prefix := range []string{l.policy.accountKey(""), l.policy.ipKey("")} {
+			if err := l.LockoutRepository.DeleteExpired(prefix, now.Add(-l.policy.Window), now); err != nil {
+				log.Printf("error deleting expired login attempts: %v", err)
+			}
+		}
+	}()
+}
+
+func (l *lockoutService) delayFor(failures int, maxFailures int) time.Duration {
+	switch {
+	case failures >= maxFailures:
+		return capped(l.policy.LockoutDuration<<(failures-maxFailures), l.policy.MaxLockout)
+	case failures >= l.policy.DelayAfter:
+		return capped(l.policy.BaseDelay<<(failures-l.policy.DelayAfter), l.policy.MaxDelay)
+	}
+	return 0
+}
+
+// capped also covers the shift overflowing to zero or negative values on
+// very long failure streaks.
+func capped(delay time.Duration, max time.Duration) time.Duration {
+	if delay <= 0 || delay > max {
+		return max
+	}
+	return delay
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAccountFailures: int(config.Envs.LOCKOUT_MAX_ACCOUNT_FAILURES),
+		MaxIPFailures:      int(config.Envs.LOCKOUT_MAX_IP_FAILURES),
+		DelayAfter:         3,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+		LockoutDuration:    time.Duration(config.Envs.LOCKOUT_DURATION) * time.Second,
+		MaxLockout:         24 * time.Hour,
+		Window:             24 * time.Hour,
+	}
+}
+
+// PinPolicy is stricter than DefaultPolicy, PINs are much easier to guess
+// than passwords. The address is the terminal the PIN was typed on.
+func PinPolicy() Policy {
+	return Policy{
+		Scope:              "pin",
+		MaxAccountFailures: int(config.Envs.PIN_MAX_FAILURES),
+		MaxIPFailures:      int(config.Envs.PIN_MAX_TERMINAL_FAILURES),
+		DelayAfter:         2,
+		BaseDelay:          time.Second,
+		MaxDelay:           5 * time.Second,
+		LockoutDuration:    time.Duration(config.Envs.PIN_LOCKOUT_DURATION) * time.Second,
+		MaxLockout:         24 * time.Hour,
+		Window:             24 * time.Hour,
+	}
+}
+
+func NewLockoutService(lockoutRepository LockoutRepository, policy Policy) LockoutService {
+	return &lockoutService{LockoutRepository: lockoutRepository, policy: policy, now: time.Now}
+}
@@ -0,0 +1,74 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+// sweepRecorder reports the DeleteExpired calls of the background sweeps.
+type sweepRecorder struct {
+	LockoutRepository
+	prefixes chan string
+}
+
+func (s *sweepRecorder) DeleteExpired(keyPrefix string, failedBefore time.Time, now time.Time) error {
+	s.prefixes <- keyPrefix
+	return s.LockoutRepository.DeleteExpired(keyPrefix, failedBefore, now)
+}
+
+func TestDeleteExpired(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should only delete attempts that no longer count", func(t *testing.T) {
+		repository := NewMemoryLockoutRepository()
+		repository.RegisterFailure("account:old@example.com", now.Add(-48*time.Hour), now.Add(-72*time.Hour))
+		repository.RegisterFailure("account:recent@example.com", now.Add(-time.Hour), now.Add(-72*time.Hour))
+		repository.RegisterFailure("account:locked@example.com", now.Add(-48*time.Hour), now.Add(-72*time.Hour))
+		repository.Lock("account:locked@example.com", now.Add(time.Hour))
+		repository.RegisterFailure("pin:account:4", now.Add(-48*time.Hour), now.Add(-72*time.Hour))
+
+		if err := repository.DeleteExpired("account:", now.Add(-24*time.Hour), now); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			key  string
+			kept bool
+		}{
+			{"account:old@example.com", false},
+			{"account:recent@example.com", true},
+			{"account:locked@example.com", true},
+			{"pin:account:4", true},
+		}
+		for _, tt := range tests {
+			attempt, _ := repository.Find(tt.key)
+			if kept := attempt.Failures > 0; kept != tt.kept {
+				t.Errorf("%s: expected kept to be %v", tt.key, tt.kept)
+			}
+		}
+	})
+
+	t.Run("should sweep the keys of its policy at most once an hour", func(t *testing.T) {
+		recorder := &sweepRecorder{LockoutRepository: NewMemoryLockoutRepository(), prefixes: make(chan string, 4)}
+		service := &lockoutService{LockoutRepository: recorder, policy: Policy{Scope: "pin", MaxAccountFailures: 5, MaxIPFailures: 20, DelayAfter: 3, Window: time.Hour}, now: func() time.Time { return now }}
+
+		service.RegisterFailure("4", "terminal-1")
+		for _, want := range []string{"pin:account:", "pin:ip:"} {
+			select {
+			case got := <-recorder.prefixes:
+				if got != want {
+					t.Errorf("expected a sweep of %q, got %q", want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected a sweep of %q", want)
+			}
+		}
+
+		service.RegisterFailure("4", "terminal-1")
+		select {
+		case got := <-recorder.prefixes:
+			t.Errorf("expected no other sweep within the hour, got one of %q", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+type TerminalRepository interface {
+	Save(terminal Terminal) (Terminal, error)
+	FindByID(id int) (Terminal, error)
+	// FindActiveByTokenHash ignores revoked terminals.
+	FindActiveByTokenHash(tokenHash string) (Terminal, error)
+	FindAll() ([]Terminal, error)
+	Revoke(id int) error
+	Touch(id int, usedAt time.Time) error
+}
+
+type terminalRepository struct {
+	*sql.DB
+}
+
+const terminalColumns = "id, name, token_hash, last_used_at, revoked_at, created_at"
+
+func scanTerminal(scan func(dest ...any) error) (Terminal, error) {
+	var terminal Terminal
+	var lastUsedAt, revokedAt sql.NullTime
+	err := scan(&terminal.ID, &terminal.Name, &terminal.Token_hash, &lastUsedAt, &revokedAt, &terminal.CreatedAt)
+	if lastUsedAt.Valid {
+		terminal.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		terminal.RevokedAt = &revokedAt.Time
+	}
+	return terminal, err
+}
+
+func (t *terminalRepository) Save(terminal Terminal) (Terminal, error) {
+	log.Printf("saving terminal %s to database", terminal.Name)
+	result, err := t.DB.Exec("INSERT INTO pos_terminals (name, token_hash, created_at) VALUES (?, ?, ?)", terminal.Name, terminal.Token_hash, terminal.CreatedAt)
+	if err != nil {
+		log.Printf("error saving terminal %s: %v", terminal.Name, err)
+		return Terminal{}, exceptions.FromDatabaseError(err, "terminal")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Terminal{}, exceptions.FromDatabaseError(err, "terminal")
+	}
+
+	terminal.ID = int(id)
+	return terminal, nil
+}
+
+func (t *terminalRepository) FindByID(id int) (Terminal, error) {
+	terminal, err := scanTerminal(t.DB.QueryRow("SELECT "+terminalColumns+" FROM pos_terminals WHERE id = ?", id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Terminal{}, exceptions.NewEntityNotFound("terminal", id)
+		}
+		log.Printf("error finding terminal %d: %v", id, err)
+		return Terminal{}, exceptions.FromDatabaseError(err, "terminal")
+	}
+	return terminal, nil
+}
+
+func (t *terminalRepository) FindActiveByTokenHash(tokenHash string) (Terminal, error) {
+	query := "SELECT " + terminalColumns + " FROM pos_terminals WHERE token_hash = ? AND revoked_at IS NULL"
+
+	terminal, err := scanTerminal(t.DB.QueryRow(query, tokenHash).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Terminal{}, exceptions.NewEntityNotFound("terminal", nil)
+		}
+		log.Printf("error finding terminal by token: %v", err)
+		return Terminal{}, exceptions.FromDatabaseError(err, "terminal")
+	}
+	return terminal, nil
+}
+
+func (t *terminalRepository) FindAll() ([]Terminal, error) {
+	rows, err := t.DB.Query("SELECT " + terminalColumns + " FROM pos_terminals ORDER BY name")
+	if err != nil {
+		log.Printf("error listing terminals: %v", err)
+		return nil, exceptions.FromDatabaseError(err, "terminal")
+	}
+	defer rows.Close()
+
+	terminals := []Terminal{}
+	for rows.Next() {
+		terminal, err := scanTerminal(rows.Scan)
+		if err != nil {
+			return nil, exceptions.FromDatabaseError(err, "terminal")
+		}
+		terminals = append(terminals, terminal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, exceptions.FromDatabaseError(err, "terminal")
+	}
+
+	return terminals, nil
+}
+
+func (t *terminalRepository) Revoke(id int) error {
+	if _, err := t.DB.Exec("UPDATE pos_terminals SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id); err != nil {
+		log.Printf("error revoking terminal %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "terminal")
+	}
+	return nil
+}
+
+func (t *terminalRepository) Touch(id int, usedAt time.Time) error {
+	if _, err := t.DB.Exec("UPDATE pos_terminals SET last_used_at = ? WHERE id = ?", usedAt, id); err != nil {
+		return exceptions.FromDatabaseError(err, "terminal")
+	}
+	return nil
+}
+
+func NewTerminalRepository(db *sql.DB) TerminalRepository {
+	return &terminalRepository{db}
+}
@@ -0,0 +1,77 @@
+package terminal
+
+import (
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+type TerminalService interface {
+	// Register returns the terminal and its token, which is only shown once.
+	Register(name string) (Terminal, string, error)
+	// Authenticate returns the active terminal the token belongs to.
+	Authenticate(token string) (Terminal, error)
+	FindAll() ([]Terminal, error)
+	Revoke(id int) error
+}
+
+type terminalService struct {
+	TerminalRepository
+}
+
+func (t *terminalService) Register(name string) (Terminal, string, error) {
+	token, err := newTerminalToken()
+	if err != nil {
+		return Terminal{}, "", exceptions.NewInternalServerError(err.Error())
+	}
+
+	terminal, err := t.TerminalRepository.Save(Terminal{
+		Name:       name,
+		Token_hash: hashToken(token),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return Terminal{}, "", err
+	}
+
+	log.Printf("terminal %s registered with ID %d", terminal.Name, terminal.ID)
+	return terminal, token, nil
+}
+
+func (t *terminalService) Authenticate(token string) (Terminal, error) {
+	if token == "" {
+		return Terminal{}, exceptions.NewUnauthorizedError("missing terminal token")
+	}
+
+	terminal, err := t.TerminalRepository.FindActiveByTokenHash(hashToken(token))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			return Terminal{}, exceptions.NewUnauthorizedError("unknown or revoked terminal")
+		}
+		return Terminal{}, err
+	}
+
+	if err := t.TerminalRepository.Touch(terminal.ID, time.Now()); err != nil {
+		log.Printf("error updating last use of terminal %d: %v", terminal.ID, err)
+	}
+
+	return terminal, nil
+}
+
+func (t *terminalService) FindAll() ([]Terminal, error) {
+	return t.TerminalRepository.FindAll()
+}
+
+func (t *terminalService) Revoke(id int) error {
+	if _, err := t.TerminalRepository.FindByID(id); err != nil {
+		return err
+	}
+
+	log.Printf("revoking terminal %d", id)
+	return t.TerminalRepository.Revoke(id)
+}
+
+func NewTerminalService(terminalRepository TerminalRepository) TerminalService {
+	return &terminalService{terminalRepository}
+}
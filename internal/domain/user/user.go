@@ -1,18 +1,75 @@
 package user
 
-import "time"
+import (
+	"go-restaurant-management/internal/shared/auth"
+	"time"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleWaiter   = "waiter"
+	RoleCashier  = "cashier"
+	RoleManager  = "manager"
+	RoleAdmin    = "admin"
+)
 
 type User struct {
-	ID            int       `json:"id"`
-	First_name    string    `json:"first_name"`
-	Last_name     string    `json:"last_name"`
-	Email         string    `json:"email"`
-	Password      string    `json:"-"`
-	Avatar        string    `json:"avatar"`
-	Phone         string    `json:"phone"`
-	Token         string    `json:"token"`
-	Refresh_token string    `json:"refresh_token"`
-	Role          string    `json:"role"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	First_name  string     `json:"first_name"`
+	Last_name   string     `json:"last_name"`
+	Email       string     `json:"email"`
+	Password    string     `json:"-"`
+	Pin_hash    string     `json:"-"`
+	Avatar      string     `json:"avatar"`
+	Phone       string     `json:"phone"`
+	Token       string     `json:"-"`
+	Role        string     `json:"role"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Verified_at *time.Time `json:"verified_at"`
+	// Deactivated users can't log in but are kept, so records made by them
+	// still point to someone
+	Deactivated_at *time.Time `json:"deactivated_at"`
+	// Anonymized users asked for their personal data to be erased
+	Anonymized_at *time.Time `json:"anonymized_at"`
+
+	Totp_secret     string     `json:"-"`
+	Totp_enabled_at *time.Time `json:"two_factor_enabled_at"`
+	Totp_last_step  int64      `json:"-"` // Last accepted TOTP step, so codes can't be replayed
+
+	// Version grows on every change to the fields above, it is the ETag of
+	// the user
+	Version int `json:"-"`
+}
+
+func (u User) IsVerified() bool {
+	return u.Verified_at != nil
+}
+
+// IsStaff reports whether the user works at the restaurant floor and may use
+// PIN logins on POS terminals.
+func (u User) IsStaff() bool {
+	return u.Role == RoleWaiter || u.Role == RoleCashier || u.Role == RoleManager
+}
+
+func (u User) IsActive() bool {
+	return u.Deactivated_at == nil
+}
+
+func (u User) TwoFactorEnabled() bool {
+	return u.Totp_enabled_at != nil
+}
+
+// UsesTwoFactor reports whether the user logs in with a second factor, or must
+// enroll one. Such users can't use PIN logins, which would bypass it.
+func (u User) UsesTwoFactor() bool {
+	return u.TwoFactorEnabled() || requiresTwoFactor(u.Role)
+}
+
+// Claims returns what tokens issued to the user say about them.
+func (u User) Claims() auth.Claims {
+	return auth.Claims{
+		UserID: u.ID,
+		Role:   u.Role,
+	}
 }
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"time"
+)
+
+var Roles = []string{RoleCustomer, RoleWaiter, RoleCashier, RoleManager, RoleAdmin}
+
+func IsValidRole(role string) bool {
+	for _, valid := range Roles {
+		if role == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// canManage reports whether actor may change users with the given role.
+// Admins manage everyone, managers only the staff below them and customers.
+func canManage(actor auth.Claims, role string) bool {
+	switch actor.Role {
+	case RoleAdmin:
+		return true
+	case RoleManager:
+		return role == RoleCustomer || role == RoleWaiter || role == RoleCashier
+	}
+	return false
+}
+
+func (u *userService) ListUsers(query utils.ListQuery) ([]User, int, error) {
+	return u.UserRepository.FindAll(query)
+}
+
+// UpdateUser saves the names, phone and role of a user changed by a manager,
+// if the user didn't change since user.Version was read. Changing the role
+// signs the user out, their tokens carry the old one.
+func (u *userService) UpdateUser(actor auth.Claims, user User) (User, error) {
+	current, err := u.manageableUser(actor, user.ID)
+	if err != nil {
+		return User{}, err
+	}
+
+	if user.Role != current.Role {
+		if user.ID == actor.UserID {
+			return User{}, exceptions.NewForbiddenError("you can't change your own role")
+		}
+		if !canManage(actor, user.Role) {
+			return User{}, exceptions.NewForbiddenError("you can't assign the role " + user.Role)
+		}
+	}
+
+	if err := u.UserRepository.Update(user); err != nil {
+		return User{}, err
+	}
+
+	if user.Role != current.Role {
+		if _, err := u.sessions.RevokeAll(user.ID); err != nil {
+			return User{}, err
+		}
+		log.Printf("role of user %s changed from %s to %s by user %d", current.Email, current.Role, user.Role, actor.UserID)
+	}
+
+	return u.UserRepository.FindByID(user.ID)
+}
+
+// DeactivateUser blocks the user from logging in and ends their sessions.
+func (u *userService) DeactivateUser(actor auth.Claims, id int) (User, error) {
+	if id == actor.UserID {
+		return User{}, exceptions.NewForbiddenError("you can't deactivate your own account")
+	}
+
+	user, err := u.manageableUser(actor, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := u.UserRepository.Deactivate(user.ID, time.Now()); err != nil {
+		return User{}, err
+	}
+
+	if _, err := u.sessions.RevokeAll(user.ID); err != nil {
+		return User{}, err
+	}
+
+	log.Printf("user %s deactivated by user %d", user.Email, actor.UserID)
+	return u.UserRepository.FindByID(user.ID)
+}
+
+func (u *userService) ReactivateUser(actor auth.Claims, id int) (User, error) {
+	user, err := u.manageableUser(actor, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	if user.Anonymized_at != nil {
+		return User{}, exceptions.NewConflictError("user", "the user asked for their data to be erased")
+	}
+
+	if err := u.UserRepository.Reactivate(user.ID); err != nil {
+		return User{}, err
+	}
+
+	log.Printf("user %s reactivated by user %d", user.Email, actor.UserID)
+	return u.UserRepository.FindByID(user.ID)
+}
+
+func (u *userService) manageableUser(actor auth.Claims, id int) (User, error) {
+	user, err := u.UserRepository.FindByID(id)
+	if err != nil {
+		return User{}, err
+	}
+
+	if !canManage(actor, user.Role) {
+		return User{}, exceptions.NewForbiddenError("you can't manage users with the role " + user.Role)
+	}
+	return user, nil
+}
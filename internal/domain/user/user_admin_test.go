@@ -0,0 +1,29 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/auth"
+	"testing"
+)
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		actor  string
+		target string
+		want   bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleManager, true},
+		{RoleManager, RoleWaiter, true},
+		{RoleManager, RoleCashier, true},
+		{RoleManager, RoleCustomer, true},
+		{RoleManager, RoleManager, false},
+		{RoleManager, RoleAdmin, false},
+		{RoleCashier, RoleWaiter, false},
+	}
+
+	for _, tt := range tests {
+		if got := canManage(auth.Claims{Role: tt.actor}, tt.target); got != tt.want {
+			t.Errorf("canManage(%s, %s) = %v, want %v", tt.actor, tt.target, got, tt.want)
+		}
+	}
+}
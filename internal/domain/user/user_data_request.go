@@ -0,0 +1,20 @@
+package user
+
+import "time"
+
+const (
+	DataRequestExport  = "export"
+	DataRequestErasure = "erasure"
+)
+
+// DataRequest records a personal data export or erasure asked by a user, kept
+// as proof that the request was handled.
+type DataRequest struct {
+	ID          int        `json:"id"`
+	User_id     int        `json:"user_id"`
+	Type        string     `json:"type"`
+	Ip          string     `json:"ip"`
+	User_agent  string     `json:"user_agent"`
+	RequestedAt time.Time  `json:"requested_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
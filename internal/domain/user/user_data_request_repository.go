@@ -0,0 +1,65 @@
+package user
+
+import (
+	"database/sql"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+)
+
+type DataRequestRepository interface {
+	Save(request DataRequest) (DataRequest, error)
+	FindByUser(userID int) ([]DataRequest, error)
+}
+
+type dataRequestRepository struct {
+	*sql.DB
+}
+
+func (d *dataRequestRepository) Save(request DataRequest) (DataRequest, error) {
+	query := "INSERT INTO user_data_requests (user_id, type, ip, user_agent, requested_at, completed_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := d.DB.Exec(query, request.User_id, request.Type, request.Ip, request.User_agent, request.RequestedAt, request.CompletedAt)
+	if err != nil {
+		log.Printf("error saving %s request of user %d: %v", request.Type, request.User_id, err)
+		return DataRequest{}, exceptions.FromDatabaseError(err, "data request")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return DataRequest{}, exceptions.FromDatabaseError(err, "data request")
+	}
+
+	request.ID = int(id)
+	return request, nil
+}
+
+func (d *dataRequestRepository) FindByUser(userID int) ([]DataRequest, error) {
+	rows, err := d.DB.Query("SELECT id, user_id, type, ip, user_agent, requested_at, completed_at FROM user_data_requests WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		log.Printf("error listing data requests of user %d: %v", userID, err)
+		return nil, exceptions.FromDatabaseError(err, "data request")
+	}
+	defer rows.Close()
+
+	requests := []DataRequest{}
+	for rows.Next() {
+		var request DataRequest
+		var completedAt sql.NullTime
+		if err := rows.Scan(&request.ID, &request.User_id, &request.Type, &request.Ip, &request.User_agent, &request.RequestedAt, &completedAt); err != nil {
+			return nil, exceptions.FromDatabaseError(err, "data request")
+		}
+		if completedAt.Valid {
+			request.CompletedAt = &completedAt.Time
+		}
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, exceptions.FromDatabaseError(err, "data request")
+	}
+
+	return requests, nil
+}
+
+func NewDataRequestRepository(db *sql.DB) DataRequestRepository {
+	return &dataRequestRepository{db}
+}
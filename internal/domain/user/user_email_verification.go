@@ -0,0 +1,108 @@
+package user
+
+import (
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"log"
+	"net/url"
+	"time"
+)
+
+func (u *userService) VerifyEmail(token string) (User, error) {
+	verificationToken, err := u.tokens.FindValid(hashToken(token), TokenPurposeEmailVerification)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			return User{}, exceptions.NewValidationError("token", "The verification token is invalid or has expired")
+		}
+		return User{}, err
+	}
+
+	if _, err := u.tokens.MarkUsed(verificationToken.ID); err != nil {
+		return User{}, err
+	}
+
+	now := time.Now()
+	if err := u.UserRepository.MarkVerified(verificationToken.User_id, now); err != nil {
+		return User{}, err
+	}
+
+	user, err := u.UserRepository.FindByID(verificationToken.User_id)
+	if err != nil {
+		return User{}, err
+	}
+
+	log.Printf("email of user %s verified", user.Email)
+	return user, nil
+}
+
+func (u *userService) ResendVerification(userID int) error {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsVerified() {
+		return exceptions.NewConflictError("email", "email already verified")
+	}
+
+	latest, err := u.tokens.FindLatest(user.ID, TokenPurposeEmailVerification)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Type != errors.NOT_FOUND {
+			return err
+		}
+	} else {
+		cooldown := time.Duration(config.Envs.EMAIL_VERIFICATION_RESEND_COOLDOWN) * time.Second
+		if wait := time.Until(latest.CreatedAt.Add(cooldown)); wait > 0 {
+			return exceptions.NewTooManyRequestsError("a verification email was sent recently", wait)
+		}
+	}
+
+	return u.sendVerification(user)
+}
+
+// IsEmailVerified reads the verification from the database, a token can be
+// older than it. Errors count as unverified.
+func (u *userService) IsEmailVerified(userID int) bool {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		log.Printf("error checking email verification of user %d: %v", userID, err)
+		return false
+	}
+	return user.IsVerified()
+}
+
+// sendVerification replaces any pending verification link with a new one.
+func (u *userService) sendVerification(user User) error {
+	token, err := newRandomToken()
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	if err := u.tokens.InvalidateAll(user.ID, TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	expiration := time.Duration(config.Envs.EMAIL_VERIFICATION_EXPIRE) * time.Second
+	_, err = u.tokens.Save(UserToken{
+		User_id:    user.ID,
+		Purpose:    TokenPurposeEmailVerification,
+		Token_hash: hashToken(token),
+		ExpiresAt:  time.Now().Add(expiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/auth/verify?token=%s", config.Envs.APP_URL, url.QueryEscape(token))
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email by opening the link below. It expires in %d hours.\n\n%s",
+			user.First_name, int(expiration.Hours()), link),
+	})
+
+	return nil
+}
@@ -1,15 +1,63 @@
 package user
 
-import "go-restaurant-management/internal/shared/types"
+import (
+	"go-restaurant-management/internal/shared/types"
+	"go-restaurant-management/internal/shared/utils"
+)
 
 func RegisterToUser(req types.RegisterUserRequest) User {
+	phone := normalizePhone(req.Phone)
+
 	return User{
 		First_name: req.First_name,
 		Last_name:  req.Last_name,
 		Email:      req.Email,
 		Password:   req.Password,
-		Phone:      req.Phone,
+		Phone:      phone,
 		Avatar:     "",
-		Role:       "customer",
+		Role:       RoleCustomer,
+	}
+}
+
+func UserToProfilePatch(user User) types.ProfilePatch {
+	return types.ProfilePatch{
+		First_name: user.First_name,
+		Last_name:  user.Last_name,
+		Phone:      user.Phone,
+	}
+}
+
+// ApplyProfilePatch copies the patched profile onto user.
+func ApplyProfilePatch(user User, patch types.ProfilePatch) User {
+	user.First_name = patch.First_name
+	user.Last_name = patch.Last_name
+	user.Phone = normalizePhone(patch.Phone)
+	return user
+}
+
+func UserToPatch(user User) types.UserPatch {
+	return types.UserPatch{
+		First_name: user.First_name,
+		Last_name:  user.Last_name,
+		Phone:      user.Phone,
+		Role:       user.Role,
+	}
+}
+
+func ApplyUserPatch(user User, patch types.UserPatch) User {
+	user = ApplyProfilePatch(user, types.ProfilePatch{
+		First_name: patch.First_name,
+		Last_name:  patch.Last_name,
+		Phone:      patch.Phone,
+	})
+	user.Role = patch.Role
+	return user
+}
+
+// Phones are stored in E.164 so lookups don't depend on how they were typed
+func normalizePhone(phone string) string {
+	if normalized, ok := utils.NormalizeBrazilianMobile(phone); ok {
+		return normalized
 	}
+	return phone
 }
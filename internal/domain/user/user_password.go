@@ -0,0 +1,60 @@
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-restaurant-management/config"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bcryptCost() int {
+	cost := int(config.Envs.BCRYPT_COST)
+	if cost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+	if cost > bcrypt.MaxCost {
+		return bcrypt.MaxCost
+	}
+	return cost
+}
+
+// HashPassword hashes passwords and PINs with the configured bcrypt cost.
+func HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// needsRehash reports whether a stored hash was generated with a lower cost
+// than the configured one. The $2a$, $2b$ and $2y$ variants only differ in
+// their prefix for passwords this short, so they are kept as they are. It is
+// called after the password matched, so the hash is always one bcrypt reads.
+func needsRehash(hashedPassword string) bool {
+	cost, _ := bcrypt.Cost([]byte(hashedPassword))
+	return cost < bcryptCost()
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// compareDummyPassword spends the same time as a real comparison so that
+// unknown emails can't be told apart from wrong passwords by response time.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcryptCost())
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// hashToken is used for refresh tokens, which are already high entropy and
+// only need to be unreadable if the database leaks.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
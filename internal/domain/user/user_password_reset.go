@@ -0,0 +1,121 @@
+package user
+
+import (
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"net/url"
+	"time"
+)
+
+// ForgotPassword sends a reset link when the email belongs to a user. It
+// returns nil for unknown emails so callers can't probe which accounts exist,
+// and issues the link in the background so known emails don't answer slower.
+func (u *userService) ForgotPassword(email string) error {
+	log.Printf("password reset requested for %s", email)
+	user, err := u.UserRepository.FindByEmail(email)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			log.Printf("password reset requested for unknown email %s", email)
+			return nil
+		}
+		return err
+	}
+
+	u.background.Add(1)
+	go func() {
+		defer u.background.Done()
+		if err := u.sendPasswordReset(user); err != nil {
+			log.Printf("error sending password reset to user %d: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+func (u *userService) sendPasswordReset(user User) error {
+	token, err := newRandomToken()
+	if err != nil {
+		return err
+	}
+
+	// Only the most recent link works
+	if err := u.tokens.InvalidateAll(user.ID, TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	expiration := time.Duration(config.Envs.PASSWORD_RESET_EXPIRE) * time.Second
+	_, err = u.tokens.Save(UserToken{
+		User_id:    user.ID,
+		Purpose:    TokenPurposePasswordReset,
+		Token_hash: hashToken(token),
+		ExpiresAt:  time.Now().Add(expiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.Envs.APP_URL, url.QueryEscape(token))
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you didn't ask for this, you can ignore this message.",
+			user.First_name, int(expiration.Minutes()), link),
+	})
+
+	return nil
+}
+
+func (u *userService) ResetPassword(token string, password string) error {
+	resetToken, err := u.tokens.FindValid(hashToken(token), TokenPurposePasswordReset)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			return exceptions.NewValidationError("token", "The reset token is invalid or has expired")
+		}
+		return err
+	}
+
+	user, err := u.UserRepository.FindByID(resetToken.User_id)
+	if err != nil {
+		return err
+	}
+
+	if utils.ContainsPersonalInfo(password, user.Email, user.First_name, user.Last_name) {
+		return exceptions.NewValidationError("password", "The field password must not contain your name or email")
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	// Sessions opened with the old password must not survive the reset, and
+	// the token must stay usable if the password couldn't be saved
+	reset, err := u.UserRepository.ResetPassword(user.ID, resetToken.ID, hashedPassword, time.Now())
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return exceptions.NewValidationError("token", "The reset token is invalid or has expired")
+	}
+
+	log.Printf("password of user %s reset successfully", user.Email)
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nYour password was just changed and you were signed out of all devices. If this wasn't you, contact the restaurant immediately.", user.First_name),
+	})
+
+	return nil
+}
+
+// notify doesn't fail the operation that sent the message. The email notifier
+// only queues it, so slow transports don't delay responses.
+func (u *userService) notify(message notifier.Message) {
+	if err := u.notifier.Send(message); err != nil {
+		log.Printf("error sending %q to %s: %v", message.Subject, message.To, err)
+	}
+}
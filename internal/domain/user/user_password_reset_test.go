@@ -0,0 +1,154 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/notifier"
+	"testing"
+	"time"
+)
+
+// blockingTokenRepository holds InvalidateAll until release is closed.
+type blockingTokenRepository struct {
+	UserTokenRepository
+	release chan struct{}
+	saved   chan UserToken
+}
+
+func (b *blockingTokenRepository) InvalidateAll(userID int, purpose string) error {
+	<-b.release
+	return nil
+}
+
+func (b *blockingTokenRepository) Save(token UserToken) (UserToken, error) {
+	b.saved <- token
+	return token, nil
+}
+
+type discardNotifier struct {
+	notifier.Notifier
+}
+
+func (discardNotifier) Send(message notifier.Message) error { return nil }
+
+func TestForgotPassword(t *testing.T) {
+	t.Run("should answer known emails before issuing the link", func(t *testing.T) {
+		tokens := &blockingTokenRepository{release: make(chan struct{}), saved: make(chan UserToken, 1)}
+		service := &userService{UserRepository: &fakeUserRepository{user: User{ID: 7, Email: "ana@example.com"}}, tokens: tokens, notifier: discardNotifier{}}
+
+		done := make(chan error, 1)
+		go func() { done <- service.ForgotPassword("ana@example.com") }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected ForgotPassword not to wait for the token to be issued")
+		}
+
+		close(tokens.release)
+		select {
+		case token := <-tokens.saved:
+			if token.User_id != 7 || token.Purpose != TokenPurposePasswordReset {
+				t.Errorf("unexpected token saved: %+v", token)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected a reset token to be issued")
+		}
+	})
+
+	t.Run("should wait for the links still being issued when closed", func(t *testing.T) {
+		tokens := &blockingTokenRepository{release: make(chan struct{}), saved: make(chan UserToken, 1)}
+		service := &userService{UserRepository: &fakeUserRepository{user: User{ID: 7, Email: "ana@example.com"}}, tokens: tokens, notifier: discardNotifier{}}
+
+		if err := service.ForgotPassword("ana@example.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		closed := make(chan struct{})
+		go func() {
+			service.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+			t.Fatal("expected Close to wait for the link")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(tokens.release)
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("expected Close to return once the link was issued")
+		}
+		if len(tokens.saved) != 1 {
+			t.Error("expected the reset token to be saved before Close returned")
+		}
+	})
+
+	t.Run("should answer unknown emails the same way", func(t *testing.T) {
+		service := &userService{UserRepository: &fakeUserRepository{user: User{ID: 7, Email: "ana@example.com"}}}
+
+		if err := service.ForgotPassword("nobody@example.com"); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+	})
+}
+
+// resetTokenRepository finds the token, whatever its hash.
+type resetTokenRepository struct {
+	UserTokenRepository
+	token UserToken
+}
+
+func (r resetTokenRepository) FindValid(tokenHash string, purpose string) (UserToken, error) {
+	return r.token, nil
+}
+
+// resettingUserRepository resets the password unless the token was used.
+type resettingUserRepository struct {
+	fakeUserRepository
+	used     bool
+	password string
+}
+
+func (r *resettingUserRepository) ResetPassword(id int, tokenID int, hashedPassword string, resetAt time.Time) (bool, error) {
+	if r.used {
+		return false, nil
+	}
+	r.used = true
+	r.password = hashedPassword
+	return true, nil
+}
+
+func TestResetPassword(t *testing.T) {
+	newService := func() (*userService, *resettingUserRepository) {
+		users := &resettingUserRepository{fakeUserRepository: fakeUserRepository{user: User{ID: 7, First_name: "Ana", Email: "ana@example.com"}}}
+		tokens := resetTokenRepository{token: UserToken{ID: 3, User_id: 7, Purpose: TokenPurposePasswordReset}}
+		return &userService{UserRepository: users, tokens: tokens, notifier: discardNotifier{}}, users
+	}
+
+	t.Run("should save the new password with the token", func(t *testing.T) {
+		service, users := newService()
+
+		if err := service.ResetPassword("token", "Bistro#Night42"); err != nil {
+			t.Fatal(err)
+		}
+		if !users.used || users.password == "" {
+			t.Error("expected the password to be reset")
+		}
+	})
+
+	t.Run("should refuse tokens used by a concurrent request", func(t *testing.T) {
+		service, users := newService()
+		users.used = true
+
+		if err := service.ResetPassword("token", "Bistro#Night42"); err == nil {
+			t.Fatal("expected an error")
+		}
+		if users.password != "" {
+			t.Error("expected the password to be kept")
+		}
+	})
+}
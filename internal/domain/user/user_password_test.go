@@ -0,0 +1,45 @@
+package user
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	current, err := HashPassword("Bistro#Night42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weaker, err := bcrypt.GenerateFromPassword([]byte("Bistro#Night42"), bcryptCost()-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherVariant := "$2y$" + current[4:]
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"should keep hashes with the configured cost", current, false},
+		{"should rehash hashes with a lower cost", string(weaker), true},
+		{"should keep other bcrypt variants", otherVariant, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRehash(tt.hash); got != tt.want {
+				t.Errorf("needsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("should verify other bcrypt variants", func(t *testing.T) {
+		if err := bcrypt.CompareHashAndPassword([]byte(otherVariant), []byte("Bistro#Night42")); err != nil {
+			t.Errorf("expected the hash to verify, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"fmt"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PersonalDataExport is everything the application keeps about a user.
+type PersonalDataExport struct {
+	Generated_at  time.Time     `json:"generated_at"`
+	Profile       User          `json:"profile"`
+	Sessions      []Session     `json:"sessions"`
+	Data_requests []DataRequest `json:"data_requests"`
+}
+
+func (u *userService) ExportPersonalData(userID int, device Device) (PersonalDataExport, error) {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return PersonalDataExport{}, err
+	}
+
+	now := time.Now()
+	if _, err := u.dataRequests.Save(newDataRequest(user.ID, DataRequestExport, device, now, &now)); err != nil {
+		return PersonalDataExport{}, err
+	}
+
+	sessions, err := u.sessions.FindActive(user.ID)
+	if err != nil {
+		return PersonalDataExport{}, err
+	}
+
+	requests, err := u.dataRequests.FindByUser(user.ID)
+	if err != nil {
+		return PersonalDataExport{}, err
+	}
+
+	log.Printf("personal data of user %d exported", user.ID)
+	return PersonalDataExport{
+		Generated_at:  now,
+		Profile:       user,
+		Sessions:      sessions,
+		Data_requests: requests,
+	}, nil
+}
+
+// ErasePersonalData anonymizes the account instead of deleting it, orders and
+// invoices must keep pointing to a customer for accounting. The account can't
+// be used afterwards.
+func (u *userService) ErasePersonalData(userID int, password string, code string, device Device) error {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Role != RoleCustomer {
+		return exceptions.NewForbiddenError("staff accounts are erased by an administrator")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return exceptions.NewUnauthorizedError("invalid password")
+	}
+	if user.TwoFactorEnabled() {
+		if err := u.checkSecondFactor(user, code); err != nil {
+			return err
+		}
+	}
+
+	// The request stays pending if the erasure fails. Its device is erased
+	// along with the rest.
+	now := time.Now()
+	if _, err := u.dataRequests.Save(newDataRequest(user.ID, DataRequestErasure, device, now, nil)); err != nil {
+		return err
+	}
+
+	// Nobody knows the new password, it only keeps the column valid
+	unusable, err := newRandomToken()
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+	hashedPassword, err := HashPassword(unusable)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	anonymized := User{
+		ID:         user.ID,
+		First_name: "Deleted",
+		Last_name:  "User",
+		Email:      fmt.Sprintf("deleted-%d@anonymized.invalid", user.ID),
+		Password:   hashedPassword,
+	}
+	if err := u.UserRepository.Erase(anonymized, now); err != nil {
+		return err
+	}
+	u.deleteStoredFile(user.Avatar)
+
+	log.Printf("personal data of user %d erased", user.ID)
+	return nil
+}
+
+func newDataRequest(userID int, requestType string, device Device, requestedAt time.Time, completedAt *time.Time) DataRequest {
+	device = device.truncated()
+	return DataRequest{
+		User_id:     userID,
+		Type:        requestType,
+		Ip:          device.Ip,
+		User_agent:  device.User_agent,
+		RequestedAt: requestedAt,
+		CompletedAt: completedAt,
+	}
+}
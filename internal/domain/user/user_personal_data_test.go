@@ -0,0 +1,166 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/storage"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeUserRepository struct {
+	UserRepository
+	user   User
+	erased *User
+	err    error
+}
+
+func (f *fakeUserRepository) FindByID(id int) (User, error) {
+	if id != f.user.ID {
+		return User{}, exceptions.NewEntityNotFound("user", id)
+	}
+	return f.user, nil
+}
+
+func (f *fakeUserRepository) FindByEmail(email string) (User, error) {
+	if email != f.user.Email {
+		return User{}, exceptions.NewEntityNotFound("user", email)
+	}
+	return f.user, nil
+}
+
+func (f *fakeUserRepository) Erase(user User, erasedAt time.Time) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.erased = &user
+	return nil
+}
+
+type fakeSessionRepository struct {
+	SessionRepository
+	sessions []Session
+}
+
+func (f *fakeSessionRepository) FindActive(userID int) ([]Session, error) {
+	return f.sessions, nil
+}
+
+type fakeDataRequestRepository struct {
+	DataRequestRepository
+	requests []DataRequest
+}
+
+func (f *fakeDataRequestRepository) Save(request DataRequest) (DataRequest, error) {
+	request.ID = len(f.requests) + 1
+	f.requests = append(f.requests, request)
+	return request, nil
+}
+
+func (f *fakeDataRequestRepository) FindByUser(userID int) ([]DataRequest, error) {
+	return f.requests, nil
+}
+
+type fakeStorage struct {
+	storage.Storage
+	deleted []string
+}
+
+func (f *fakeStorage) KeyFromURL(url string) (string, bool) { return url, url != "" }
+
+func (f *fakeStorage) Delete(key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func TestPersonalData(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer := User{ID: 7, First_name: "Ana", Last_name: "Souza", Email: "ana@example.com", Password: string(hashed), Avatar: "avatars/7.png", Role: RoleCustomer}
+	device := Device{Ip: "203.0.113.9", User_agent: "phone"}
+
+	newService := func(user User) (*userService, *fakeUserRepository, *fakeDataRequestRepository, *fakeStorage) {
+		users := &fakeUserRepository{user: user}
+		requests := &fakeDataRequestRepository{}
+		files := &fakeStorage{}
+		sessions := &fakeSessionRepository{sessions: []Session{{ID: "phone", User_id: user.ID}}}
+		return &userService{UserRepository: users, sessions: sessions, dataRequests: requests, storage: files}, users, requests, files
+	}
+
+	t.Run("should export the profile, sessions and data requests", func(t *testing.T) {
+		service, _, requests, _ := newService(customer)
+
+		export, err := service.ExportPersonalData(customer.ID, device)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if export.Profile.Email != customer.Email || len(export.Sessions) != 1 {
+			t.Errorf("unexpected export: %+v", export)
+		}
+		if len(requests.requests) != 1 || requests.requests[0].Type != DataRequestExport || requests.requests[0].CompletedAt == nil {
+			t.Errorf("expected a completed export request, got %+v", requests.requests)
+		}
+		if len(export.Data_requests) != 1 {
+			t.Errorf("expected the export to list its own request, got %+v", export.Data_requests)
+		}
+	})
+
+	t.Run("should anonymize the account and delete the avatar", func(t *testing.T) {
+		service, users, requests, files := newService(customer)
+
+		if err := service.ErasePersonalData(customer.ID, "correct horse", "", device); err != nil {
+			t.Fatal(err)
+		}
+		if users.erased == nil || users.erased.Email != "deleted-7@anonymized.invalid" || users.erased.First_name != "Deleted" {
+			t.Fatalf("expected the account to be anonymized, got %+v", users.erased)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(users.erased.Password), []byte("correct horse")) == nil {
+			t.Error("expected the old password to stop working")
+		}
+		if len(requests.requests) != 1 || requests.requests[0].Type != DataRequestErasure {
+			t.Errorf("expected an erasure request, got %+v", requests.requests)
+		}
+		if len(files.deleted) != 1 || files.deleted[0] != customer.Avatar {
+			t.Errorf("expected the avatar to be deleted, got %v", files.deleted)
+		}
+	})
+
+	t.Run("should keep the avatar when the erasure fails", func(t *testing.T) {
+		service, users, _, files := newService(customer)
+		users.err = exceptions.NewInternalServerError("connection lost")
+
+		if err := service.ErasePersonalData(customer.ID, "correct horse", "", device); err == nil {
+			t.Fatal("expected the error to be returned")
+		}
+		if len(files.deleted) != 0 {
+			t.Errorf("expected the avatar to be kept, got %v", files.deleted)
+		}
+	})
+
+	t.Run("should refuse wrong passwords", func(t *testing.T) {
+		service, users, requests, _ := newService(customer)
+
+		if err := service.ErasePersonalData(customer.ID, "wrong", "", device); err == nil {
+			t.Fatal("expected an error")
+		}
+		if users.erased != nil || len(requests.requests) != 0 {
+			t.Error("expected nothing to be erased or recorded")
+		}
+	})
+
+	t.Run("should refuse staff accounts", func(t *testing.T) {
+		waiter := customer
+		waiter.Role = RoleWaiter
+		service, users, _, _ := newService(waiter)
+
+		if err := service.ErasePersonalData(waiter.ID, "correct horse", "", device); err == nil {
+			t.Fatal("expected an error")
+		}
+		if users.erased != nil {
+			t.Error("expected the account to be kept")
+		}
+	})
+}
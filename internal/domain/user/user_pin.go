@@ -0,0 +1,88 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetPin sets the PIN staff use to log in on POS terminals. The password is
+// required so a borrowed, logged in device can't be used to set one.
+func (u *userService) SetPin(userID int, password string, pin string) error {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsStaff() {
+		return exceptions.NewForbiddenError("only staff accounts can use PIN login")
+	}
+	if user.UsesTwoFactor() {
+		return exceptions.NewForbiddenError("accounts with two-factor authentication can't use PIN login")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return exceptions.NewUnauthorizedError("invalid password")
+	}
+
+	if isTrivialPin(pin) {
+		return exceptions.NewValidationError("pin", "The PIN must not be a repeated or sequential number")
+	}
+
+	hashedPin, err := HashPassword(pin)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	return u.UserRepository.UpdatePin(user.ID, hashedPin)
+}
+
+// PinLogin issues a POS scoped token. The caller must have authenticated the
+// terminal and applied the PIN lockout policy.
+func (u *userService) PinLogin(userID int, pin string, device Device) (User, auth.TokenPair, error) {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			compareDummyPassword(pin)
+			return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid user or PIN")
+		}
+		return User{}, auth.TokenPair{}, err
+	}
+
+	if !user.IsStaff() || user.Pin_hash == "" {
+		compareDummyPassword(pin)
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid user or PIN")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Pin_hash), []byte(pin)); err != nil {
+		log.Printf("invalid PIN for user %s", user.Email)
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid user or PIN")
+	}
+
+	if !user.IsActive() {
+		return User{}, auth.TokenPair{}, exceptions.NewAccountDeactivatedError()
+	}
+	if user.UsesTwoFactor() {
+		return User{}, auth.TokenPair{}, exceptions.NewForbiddenError("accounts with two-factor authentication can't use PIN login")
+	}
+
+	tokens, err := u.issuePOSToken(user, device)
+	if err != nil {
+		return User{}, auth.TokenPair{}, err
+	}
+
+	log.Printf("user %s logged in with PIN on %s", user.Email, device.User_agent)
+	return user, tokens, nil
+}
+
+// isTrivialPin rejects PINs like 0000 or 123456, the first ones anyone tries.
+func isTrivialPin(pin string) bool {
+	if strings.Count(pin, pin[:1]) == len(pin) {
+		return true
+	}
+	return strings.Contains("0123456789012345", pin) || strings.Contains("9876543210987654", pin)
+}
@@ -0,0 +1,71 @@
+package user
+
+import (
+	"go-restaurant-management/internal/shared/errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestIsTrivialPin(t *testing.T) {
+	tests := []struct {
+		pin     string
+		trivial bool
+	}{
+		{"0000", true},
+		{"1234", true},
+		{"456789", true},
+		{"9876", true},
+		{"8901", true},
+		{"4821", false},
+		{"1122", false},
+		{"739164", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTrivialPin(tt.pin); got != tt.trivial {
+			t.Errorf("isTrivialPin(%q) = %v, want %v", tt.pin, got, tt.trivial)
+		}
+	}
+}
+
+func TestPinTwoFactor(t *testing.T) {
+	pin, err := bcrypt.GenerateFromPassword([]byte("4821"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte("Bistro#Night42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabledAt := time.Now()
+
+	tests := []struct {
+		name string
+		user User
+	}{
+		{"should refuse roles that require two-factor", User{ID: 4, Role: RoleManager}},
+		{"should refuse users who enabled two-factor", User{ID: 4, Role: RoleWaiter, Totp_enabled_at: &enabledAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.user.Pin_hash = string(pin)
+			tt.user.Password = string(password)
+			service := &userService{UserRepository: &fakeUserRepository{user: tt.user}}
+
+			if _, _, err := service.PinLogin(4, "4821", Device{}); !isForbidden(err) {
+				t.Errorf("expected PinLogin to be forbidden, got %v", err)
+			}
+			if err := service.SetPin(4, "Bistro#Night42", "7395"); !isForbidden(err) {
+				t.Errorf("expected SetPin to be forbidden, got %v", err)
+			}
+		})
+	}
+}
+
+func isForbidden(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Type == errors.FORBIDDEN
+}
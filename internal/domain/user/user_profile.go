@@ -0,0 +1,142 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"go-restaurant-management/internal/shared/utils"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Larger images are refused before decoding, a small file can decode to
+// gigabytes of pixels
+const avatarMaxPixels = 40_000_000
+
+// UpdateProfile fails with PRECONDITION_FAILED when the user changed since
+// user.Version was read.
+func (u *userService) UpdateProfile(user User) (User, error) {
+	current, err := u.UserRepository.FindByID(user.ID)
+	if err != nil {
+		return User{}, err
+	}
+
+	// The role isn't part of the profile
+	user.Role = current.Role
+	if err := u.UserRepository.Update(user); err != nil {
+		return User{}, err
+	}
+
+	log.Printf("profile of user %s updated", user.Email)
+	return u.UserRepository.FindByID(user.ID)
+}
+
+// ChangePassword signs out every other session, the current one stays.
+func (u *userService) ChangePassword(userID int, currentPassword string, password string, sessionID string) error {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return exceptions.NewValidationError("current_password", "The current password is incorrect")
+	}
+
+	if utils.ContainsPersonalInfo(password, user.Email, user.First_name, user.Last_name) {
+		return exceptions.NewValidationError("password", "The field password must not contain your name or email")
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	if err := u.UserRepository.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	if err := u.sessions.RevokeOthers(user.ID, sessionID); err != nil {
+		return err
+	}
+
+	log.Printf("password of user %s changed", user.Email)
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nYour password was just changed and your other devices were signed out. If this wasn't you, reset your password and contact the restaurant immediately.", user.First_name),
+	})
+
+	return nil
+}
+
+// UpdateAvatar stores the image as a square of AVATAR_SIZE pixels. PNG and GIF
+// are kept as PNG to preserve transparency, anything else becomes JPEG.
+func (u *userService) UpdateAvatar(userID int, content []byte) (User, error) {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return User{}, err
+	}
+
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return User{}, exceptions.NewValidationError("avatar", "The avatar must be a JPEG, PNG or GIF image")
+	}
+	if imageConfig.Width*imageConfig.Height > avatarMaxPixels {
+		return User{}, exceptions.NewValidationError("avatar", "The avatar dimensions are too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return User{}, exceptions.NewValidationError("avatar", "The avatar image is corrupted")
+	}
+
+	resized := utils.ResizeSquare(img, int(config.Envs.AVATAR_SIZE))
+
+	var encoded bytes.Buffer
+	extension := "jpg"
+	if format == "png" || format == "gif" {
+		extension = "png"
+		err = png.Encode(&encoded, resized)
+	} else {
+		err = jpeg.Encode(&encoded, resized, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return User{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	// A new name on each upload, so caches never serve the old avatar
+	key := fmt.Sprintf("avatars/%d-%d.%s", user.ID, time.Now().UnixNano(), extension)
+	url, err := u.storage.Put(key, &encoded)
+	if err != nil {
+		log.Printf("error storing avatar of user %s: %v", user.Email, err)
+		return User{}, exceptions.NewInternalServerError("could not store the avatar")
+	}
+
+	if err := u.UserRepository.UpdateAvatar(user.ID, url); err != nil {
+		u.deleteStoredFile(url)
+		return User{}, err
+	}
+
+	u.deleteStoredFile(user.Avatar)
+
+	log.Printf("avatar of user %s updated", user.Email)
+	return u.UserRepository.FindByID(user.ID)
+}
+
+func (u *userService) deleteStoredFile(url string) {
+	key, ok := u.storage.KeyFromURL(url)
+	if !ok {
+		return
+	}
+	if err := u.storage.Delete(key); err != nil {
+		log.Printf("error deleting stored file %s: %v", key, err)
+	}
+}
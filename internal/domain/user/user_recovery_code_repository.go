@@ -0,0 +1,73 @@
+package user
+
+import (
+	"database/sql"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+// RecoveryCodeRepository stores the hashes of the single-use codes that
+// replace a TOTP code when the user loses their authenticator.
+type RecoveryCodeRepository interface {
+	// ReplaceAll deletes the user's codes and stores the new ones.
+	ReplaceAll(userID int, codeHashes []string) error
+	// Use returns false when the code doesn't exist or was already used.
+	Use(userID int, codeHash string) (bool, error)
+	DeleteAll(userID int) error
+}
+
+type recoveryCodeRepository struct {
+	*sql.DB
+}
+
+func (r *recoveryCodeRepository) ReplaceAll(userID int, codeHashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return exceptions.FromDatabaseError(err, "recovery code")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		log.Printf("error deleting recovery codes of user %d: %v", userID, err)
+		return exceptions.FromDatabaseError(err, "recovery code")
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, codeHash); err != nil {
+			log.Printf("error saving recovery code of user %d: %v", userID, err)
+			return exceptions.FromDatabaseError(err, "recovery code")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return exceptions.FromDatabaseError(err, "recovery code")
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) Use(userID int, codeHash string) (bool, error) {
+	result, err := r.DB.Exec("UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", time.Now(), userID, codeHash)
+	if err != nil {
+		log.Printf("error using recovery code of user %d: %v", userID, err)
+		return false, exceptions.FromDatabaseError(err, "recovery code")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "recovery code")
+	}
+	return affected == 1, nil
+}
+
+func (r *recoveryCodeRepository) DeleteAll(userID int) error {
+	if _, err := r.DB.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		log.Printf("error deleting recovery codes of user %d: %v", userID, err)
+		return exceptions.FromDatabaseError(err, "recovery code")
+	}
+	return nil
+}
+
+func NewRecoveryCodeRepository(db *sql.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db}
+}
@@ -2,18 +2,97 @@ package user
 
 import (
 	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/utils"
 	"log"
+	"time"
 )
 
 type UserRepository interface {
 	Save(user User) (User, error)
 	FindByEmail(email string) (User, error)
+	FindByID(id int) (User, error)
+	// FindAll returns the users of a ListSpec query and the total number of
+	// matches.
+	FindAll(query utils.ListQuery) ([]User, int, error)
+	// Update saves the names, phone and role if the stored version still is
+	// user.Version, and fails with PRECONDITION_FAILED otherwise.
+	Update(user User) error
+	UpdateAvatar(id int, avatar string) error
+	UpdatePassword(id int, hashedPassword string) error
+	// ResetPassword uses the reset token, saves the password and revokes the
+	// sessions of the user in one transaction. It returns false, changing
+	// nothing, when the token was already used by a concurrent request.
+	ResetPassword(id int, tokenID int, hashedPassword string, resetAt time.Time) (bool, error)
+	UpdatePin(id int, hashedPin string) error
+	Deactivate(id int, deactivatedAt time.Time) error
+	Reactivate(id int) error
+	// Erase replaces the personal fields with the ones of user and deactivates
+	// the account, in one transaction with the removal of its credentials,
+	// 2FA, sessions and tokens and of the addresses and devices recorded in
+	// its data requests. Pending data requests are completed.
+	Erase(user User, erasedAt time.Time) error
+	MarkVerified(id int, verifiedAt time.Time) error
+	// SetTOTPSecret stores a secret pending confirmation, disabling 2FA until then.
+	SetTOTPSecret(id int, secret string) error
+	EnableTOTP(id int, enabledAt time.Time) error
+	DisableTOTP(id int) error
+	// UseTOTPStep returns false when the step, or a later one, was already used.
+	UseTOTPStep(id int, step int64) (bool, error)
+}
+
+// ListSpec is what the user list accepts.
+var ListSpec = utils.ListSpec{
+	DefaultLimit: 20,
+	MaxLimit:     100,
+	Sorts: map[string]string{
+		"id":         "id",
+		"first_name": "first_name",
+		"last_name":  "last_name",
+		"email":      "email",
+		"role":       "role",
+		"created_at": "created_at",
+	},
+	DefaultSort: "id",
+	KeyColumn:   "id",
+	Filters: map[string]utils.Filter{
+		"role":  {Columns: []string{"role"}, Allowed: Roles},
+		"email": {Columns: []string{"email"}, Contains: true},
+		"name":  {Columns: []string{"first_name", "last_name"}, Contains: true},
+		"status": {Conditions: map[string]string{
+			"active":      "deactivated_at IS NULL",
+			"deactivated": "deactivated_at IS NOT NULL",
+		}},
+	},
 }
 
 type userRepository struct {
 	*sql.DB
 }
 
+const userColumns = "id, first_name, last_name, email, password, COALESCE(pin_hash, ''), phone, COALESCE(avatar, ''), role, created_at, updated_at, verified_at, deactivated_at, anonymized_at, COALESCE(totp_secret, ''), totp_enabled_at, totp_last_step, version"
+
+func scanUser(scan func(dest ...any) error) (User, error) {
+	var user User
+	var verifiedAt, deactivatedAt, anonymizedAt, totpEnabledAt sql.NullTime
+	err := scan(&user.ID, &user.First_name, &user.Last_name, &user.Email, &user.Password, &user.Pin_hash, &user.Phone, &user.Avatar, &user.Role, &user.CreatedAt, &user.UpdatedAt, &verifiedAt, &deactivatedAt, &anonymizedAt,
+		&user.Totp_secret, &totpEnabledAt, &user.Totp_last_step, &user.Version)
+	if verifiedAt.Valid {
+		user.Verified_at = &verifiedAt.Time
+	}
+	if deactivatedAt.Valid {
+		user.Deactivated_at = &deactivatedAt.Time
+	}
+	if anonymizedAt.Valid {
+		user.Anonymized_at = &anonymizedAt.Time
+	}
+	if totpEnabledAt.Valid {
+		user.Totp_enabled_at = &totpEnabledAt.Time
+	}
+	return user, err
+}
+
 func (u *userRepository) Save(user User) (User, error) {
 	log.Printf("saving user %s to database", user.Email)
 	query := "INSERT INTO users (first_name, last_name, email, password, phone, avatar, role) VALUES (?, ?, ?, ?, ?, ?, ?)"
@@ -21,14 +100,14 @@ func (u *userRepository) Save(user User) (User, error) {
 	result, err := u.DB.Exec(query, user.First_name, user.Last_name, user.Email, user.Password, user.Phone, user.Avatar, user.Role)
 	if err != nil {
 		log.Printf("error executing insert for user %s: %v", user.Email, err)
-		return User{}, err
+		return User{}, exceptions.FromDatabaseError(err, "user")
 	}
 
 	// Obter o ID do usuário inserido
 	userID, err := result.LastInsertId()
 	if err != nil {
 		log.Printf("error getting last insert ID for user %s: %v", user.Email, err)
-		return User{}, err
+		return User{}, exceptions.FromDatabaseError(err, "user")
 	}
 
 	// Definir o ID no usuário
@@ -41,20 +120,263 @@ func (u *userRepository) Save(user User) (User, error) {
 
 func (u *userRepository) FindByEmail(email string) (User, error) {
 	log.Printf("finding user %s in database", email)
-	query := "SELECT id, first_name, last_name, email, phone, avatar, role FROM users WHERE email = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE email = ?"
 
-	row := u.DB.QueryRow(query, email)
-	var user User
-	err := row.Scan(&user.ID, &user.First_name, &user.Last_name, &user.Email, &user.Phone, &user.Avatar, &user.Role)
+	user, err := scanUser(u.DB.QueryRow(query, email).Scan)
 	if err != nil {
 		log.Printf("error finding user %s: %v", email, err)
-		return User{}, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, exceptions.NewEntityNotFound("user", email)
+		}
+		return User{}, exceptions.FromDatabaseError(err, "user")
 	}
 
 	log.Printf("user %s found successfully", email)
 	return user, nil
 }
 
+func (u *userRepository) FindByID(id int) (User, error) {
+	log.Printf("finding user %d in database", id)
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
+
+	user, err := scanUser(u.DB.QueryRow(query, id).Scan)
+	if err != nil {
+		log.Printf("error finding user %d: %v", id, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, exceptions.NewEntityNotFound("user", id)
+		}
+		return User{}, exceptions.FromDatabaseError(err, "user")
+	}
+
+	return user, nil
+}
+
+func (u *userRepository) FindAll(query utils.ListQuery) ([]User, int, error) {
+	where, whereArgs := query.WhereSQL()
+
+	var total int
+	if err := u.DB.QueryRow("SELECT COUNT(*) FROM users"+where, whereArgs...).Scan(&total); err != nil {
+		log.Printf("error counting users: %v", err)
+		return nil, 0, exceptions.FromDatabaseError(err, "user")
+	}
+
+	clauses, args := query.SQL()
+	rows, err := u.DB.Query("SELECT "+userColumns+" FROM users"+clauses, args...)
+	if err != nil {
+		log.Printf("error listing users: %v", err)
+		return nil, 0, exceptions.FromDatabaseError(err, "user")
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, 0, exceptions.FromDatabaseError(err, "user")
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, exceptions.FromDatabaseError(err, "user")
+	}
+
+	return users, total, nil
+}
+
+func (u *userRepository) Update(user User) error {
+	log.Printf("updating user %d", user.ID)
+	query := "UPDATE users SET first_name = ?, last_name = ?, phone = ?, role = ?, version = version + 1 WHERE id = ? AND version = ?"
+
+	result, err := u.DB.Exec(query, user.First_name, user.Last_name, user.Phone, user.Role, user.ID, user.Version)
+	if err != nil {
+		log.Printf("error updating user %d: %v", user.ID, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	if affected == 0 {
+		log.Printf("user %d changed since version %d", user.ID, user.Version)
+		return exceptions.NewPreconditionFailedError("user")
+	}
+	return nil
+}
+
+func (u *userRepository) UpdateAvatar(id int, avatar string) error {
+	_, err := u.DB.Exec("UPDATE users SET avatar = NULLIF(?, ''), version = version + 1 WHERE id = ?", avatar, id)
+	if err != nil {
+		log.Printf("error updating avatar of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) UpdatePassword(id int, hashedPassword string) error {
+	log.Printf("updating password of user %d", id)
+	_, err := u.DB.Exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, id)
+	if err != nil {
+		log.Printf("error updating password of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) ResetPassword(id int, tokenID int, hashedPassword string, resetAt time.Time) (bool, error) {
+	log.Printf("resetting password of user %d", id)
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "user")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE user_tokens SET used_at = ? WHERE id = ? AND user_id = ? AND used_at IS NULL", resetAt, tokenID, id)
+	if err != nil {
+		log.Printf("error marking token %d as used: %v", tokenID, err)
+		return false, exceptions.FromDatabaseError(err, "token")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "token")
+	}
+	if affected != 1 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, id); err != nil {
+		log.Printf("error updating password of user %d: %v", id, err)
+		return false, exceptions.FromDatabaseError(err, "user")
+	}
+
+	if _, err := tx.Exec("UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", resetAt, id); err != nil {
+		log.Printf("error revoking sessions of user %d: %v", id, err)
+		return false, exceptions.FromDatabaseError(err, "session")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, exceptions.FromDatabaseError(err, "user")
+	}
+	return true, nil
+}
+
+func (u *userRepository) UpdatePin(id int, hashedPin string) error {
+	log.Printf("updating PIN of user %d", id)
+	_, err := u.DB.Exec("UPDATE users SET pin_hash = ? WHERE id = ?", hashedPin, id)
+	if err != nil {
+		log.Printf("error updating PIN of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) Deactivate(id int, deactivatedAt time.Time) error {
+	_, err := u.DB.Exec("UPDATE users SET deactivated_at = ?, version = version + 1 WHERE id = ? AND deactivated_at IS NULL", deactivatedAt, id)
+	if err != nil {
+		log.Printf("error deactivating user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) Reactivate(id int) error {
+	_, err := u.DB.Exec("UPDATE users SET deactivated_at = NULL, version = version + 1 WHERE id = ?", id)
+	if err != nil {
+		log.Printf("error reactivating user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) Erase(user User, erasedAt time.Time) error {
+	tx, err := u.DB.Begin()
+	if err != nil {
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET first_name = ?, last_name = ?, email = ?, phone = ?, avatar = NULL, password = ?, pin_hash = NULL,
+		token = NULL, totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0,
+		deactivated_at = COALESCE(deactivated_at, ?), anonymized_at = ?, version = version + 1 WHERE id = ?`
+	if _, err := tx.Exec(query, user.First_name, user.Last_name, user.Email, user.Phone, user.Password, erasedAt, erasedAt, user.ID); err != nil {
+		log.Printf("error anonymizing user %d: %v", user.ID, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+
+	for _, query := range []string{
+		"DELETE FROM user_sessions WHERE user_id = ?",
+		"DELETE FROM user_recovery_codes WHERE user_id = ?",
+		"DELETE FROM user_tokens WHERE user_id = ?",
+	} {
+		if _, err := tx.Exec(query, user.ID); err != nil {
+			log.Printf("error erasing the records of user %d: %v", user.ID, err)
+			return exceptions.FromDatabaseError(err, "user")
+		}
+	}
+
+	// The requests are kept as proof of the erasure, without the device
+	query = "UPDATE user_data_requests SET ip = '', user_agent = '', completed_at = COALESCE(completed_at, ?) WHERE user_id = ?"
+	if _, err := tx.Exec(query, erasedAt, user.ID); err != nil {
+		log.Printf("error erasing the data requests of user %d: %v", user.ID, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) MarkVerified(id int, verifiedAt time.Time) error {
+	_, err := u.DB.Exec("UPDATE users SET verified_at = ?, version = version + 1 WHERE id = ? AND verified_at IS NULL", verifiedAt, id)
+	if err != nil {
+		log.Printf("error marking user %d as verified: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) SetTOTPSecret(id int, secret string) error {
+	_, err := u.DB.Exec("UPDATE users SET totp_secret = ?, totp_enabled_at = NULL, totp_last_step = 0, version = version + 1 WHERE id = ?", secret, id)
+	if err != nil {
+		log.Printf("error setting totp secret of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) EnableTOTP(id int, enabledAt time.Time) error {
+	_, err := u.DB.Exec("UPDATE users SET totp_enabled_at = ?, version = version + 1 WHERE id = ? AND totp_secret IS NOT NULL", enabledAt, id)
+	if err != nil {
+		log.Printf("error enabling totp of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) DisableTOTP(id int) error {
+	_, err := u.DB.Exec("UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, version = version + 1 WHERE id = ?", id)
+	if err != nil {
+		log.Printf("error disabling totp of user %d: %v", id, err)
+		return exceptions.FromDatabaseError(err, "user")
+	}
+	return nil
+}
+
+func (u *userRepository) UseTOTPStep(id int, step int64) (bool, error) {
+	result, err := u.DB.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, id, step)
+	if err != nil {
+		log.Printf("error saving totp step of user %d: %v", id, err)
+		return false, exceptions.FromDatabaseError(err, "user")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "user")
+	}
+	return affected == 1, nil
+}
+
 func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db}
 }
@@ -1,8 +1,15 @@
 package user
 
 import (
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors"
 	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"go-restaurant-management/internal/shared/storage"
+	"go-restaurant-management/internal/shared/utils"
 	"log"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -10,26 +17,71 @@ import (
 type UserService interface {
 	Register(user User) (User, error)
 	FindByEmail(email string) (User, error)
+	FindByID(id int) (User, error)
+	Login(email string, password string, device Device) (User, auth.TokenPair, error)
+	Refresh(refreshToken string, device Device) (User, auth.TokenPair, error)
+	ForgotPassword(email string) error
+	ResetPassword(token string, password string) error
+	VerifyEmail(token string) (User, error)
+	ResendVerification(userID int) error
+	IsEmailVerified(userID int) bool
+	VerifyTwoFactor(challengeToken string, code string, device Device) (User, auth.TokenPair, error)
+	SetupTwoFactor(userID int) (TwoFactorSetup, error)
+	EnableTwoFactor(claims auth.Claims, code string, device Device) ([]string, auth.TokenPair, error)
+	DisableTwoFactor(userID int, password string, code string) error
+	RegenerateRecoveryCodes(userID int, code string) ([]string, error)
+	Logout(sessionID string) error
+	LogoutAll(userID int) (int, error)
+	ListSessions(userID int, currentSessionID string) ([]Session, error)
+	IsSessionActive(sessionID string) bool
+	SetPin(userID int, password string, pin string) error
+	PinLogin(userID int, pin string, device Device) (User, auth.TokenPair, error)
+	UpdateProfile(user User) (User, error)
+	ChangePassword(userID int, currentPassword string, password string, sessionID string) error
+	UpdateAvatar(userID int, content []byte) (User, error)
+	ListUsers(query utils.ListQuery) ([]User, int, error)
+	UpdateUser(actor auth.Claims, user User) (User, error)
+	DeactivateUser(actor auth.Claims, id int) (User, error)
+	ReactivateUser(actor auth.Claims, id int) (User, error)
+	ExportPersonalData(userID int, device Device) (PersonalDataExport, error)
+	ErasePersonalData(userID int, password string, code string, device Device) error
+	// Close waits for the work still running in the background, so the
+	// notifier can be closed after it
+	Close()
 }
 
 type userService struct {
 	UserRepository
+	tokens        UserTokenRepository
+	recoveryCodes RecoveryCodeRepository
+	sessions      SessionRepository
+	dataRequests  DataRequestRepository
+	notifier      notifier.Notifier
+	storage       storage.Storage
+	// background tracks the password resets issued after ForgotPassword
+	// returned
+	background sync.WaitGroup
 }
 
 func (u *userService) Register(user User) (User, error) {
 	log.Printf("starting to register user %s", user.Email)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
+	hashedPassword, err := HashPassword(user.Password)
 	if err != nil {
 		log.Printf("error generating password hash for user %s: %v", user.Email, err)
 		return User{}, exceptions.NewInternalServerError(err.Error())
 	}
 
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	user, err = u.UserRepository.Save(user)
 	if err != nil {
 		log.Printf("error saving user %s: %v", user.Email, err)
-		return User{}, exceptions.NewInternalServerError(err.Error())
+		return User{}, err
+	}
+
+	// The account is created even if the email can't be sent, the user can ask for a new one
+	if err := u.sendVerification(user); err != nil {
+		log.Printf("error sending verification email to user %s: %v", user.Email, err)
 	}
 
 	log.Printf("user %s registered successfully in service", user.Email)
@@ -41,13 +93,131 @@ func (u *userService) FindByEmail(email string) (User, error) {
 	user, err := u.UserRepository.FindByEmail(email)
 	if err != nil {
 		log.Printf("error finding user %s: %v", email, err)
-		return User{}, exceptions.NewEntityNotFound("user", email)
+		return User{}, err
 	}
 
 	log.Printf("user %s found successfully in service", email)
 	return user, nil
 }
 
-func NewUserService(userRepository UserRepository) UserService {
-	return &userService{userRepository}
+func (u *userService) FindByID(id int) (User, error) {
+	return u.UserRepository.FindByID(id)
+}
+
+func (u *userService) Login(email string, password string, device Device) (User, auth.TokenPair, error) {
+	log.Printf("user %s attempting to log in", email)
+	user, err := u.UserRepository.FindByEmail(email)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.NOT_FOUND {
+			compareDummyPassword(password)
+			return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid email or password")
+		}
+		return User{}, auth.TokenPair{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		log.Printf("invalid password for user %s", email)
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid email or password")
+	}
+
+	// Checked after the password so the status of an account isn't revealed
+	if !user.IsActive() {
+		log.Printf("deactivated user %s attempted to log in", email)
+		return User{}, auth.TokenPair{}, exceptions.NewAccountDeactivatedError()
+	}
+
+	u.upgradePasswordHash(user, password)
+
+	if user.TwoFactorEnabled() || requiresTwoFactor(user.Role) {
+		return User{}, auth.TokenPair{}, u.twoFactorChallenge(user)
+	}
+
+	tokens, err := u.issueTokens(user, device)
+	if err != nil {
+		return User{}, auth.TokenPair{}, err
+	}
+
+	log.Printf("user %s logged in successfully", email)
+	return user, tokens, nil
+}
+
+func (u *userService) Refresh(refreshToken string, device Device) (User, auth.TokenPair, error) {
+	claims, err := auth.ParseJWT(refreshToken, auth.RefreshToken)
+	if err != nil {
+		log.Printf("invalid refresh token: %v", err)
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid refresh token")
+	}
+
+	session, err := u.sessions.FindByID(claims.SessionID)
+	if err != nil || session.User_id != claims.UserID || !session.IsActive(time.Now()) {
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid refresh token")
+	}
+
+	user, err := u.UserRepository.FindByID(claims.UserID)
+	if err != nil {
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid refresh token")
+	}
+	if !user.IsActive() {
+		return User{}, auth.TokenPair{}, exceptions.NewAccountDeactivatedError()
+	}
+
+	newClaims := user.Claims()
+	newClaims.SessionID = session.ID
+	tokens, err := auth.CreateTokenPair(newClaims)
+	if err != nil {
+		return User{}, auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	// Only the latest refresh token of a session is accepted. A reused one was
+	// probably stolen, so the whole session is revoked.
+	rotated, err := u.sessions.Rotate(session.ID, hashToken(refreshToken), hashToken(tokens.Refresh_token), device.truncated(), sessionExpiration())
+	if err != nil {
+		return User{}, auth.TokenPair{}, err
+	}
+	if !rotated {
+		log.Printf("refresh token of session %s reused, revoking it", session.ID)
+		if err := u.sessions.Revoke(session.ID); err != nil {
+			return User{}, auth.TokenPair{}, err
+		}
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid refresh token")
+	}
+
+	return user, tokens, nil
+}
+
+// upgradePasswordHash rehashes the password with the configured cost when the
+// stored hash is weaker. Failures are logged only, the login still succeeds.
+func (u *userService) upgradePasswordHash(user User, password string) {
+	if !needsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		log.Printf("error rehashing password for user %s: %v", user.Email, err)
+		return
+	}
+
+	if err := u.UserRepository.UpdatePassword(user.ID, hashedPassword); err != nil {
+		log.Printf("error saving rehashed password for user %s: %v", user.Email, err)
+		return
+	}
+
+	log.Printf("password hash of user %s upgraded", user.Email)
+}
+
+func NewUserService(userRepository UserRepository, tokenRepository UserTokenRepository, recoveryCodeRepository RecoveryCodeRepository, sessionRepository SessionRepository, dataRequestRepository DataRequestRepository, notifier notifier.Notifier, storage storage.Storage) UserService {
+	return &userService{
+		UserRepository: userRepository,
+		tokens:         tokenRepository,
+		recoveryCodes:  recoveryCodeRepository,
+		sessions:       sessionRepository,
+		dataRequests:   dataRequestRepository,
+		notifier:       notifier,
+		storage:        storage,
+	}
+}
+
+func (u *userService) Close() {
+	u.background.Wait()
 }
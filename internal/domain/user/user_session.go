@@ -0,0 +1,40 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Session is a login on one device. Access tokens carry the session ID, so
+// revoking the session signs the device out before its tokens expire.
+type Session struct {
+	ID                 string     `json:"id"`
+	User_id            int        `json:"user_id"`
+	Refresh_token_hash string     `json:"-"`
+	User_agent         string     `json:"user_agent"`
+	Ip                 string     `json:"ip"`
+	CreatedAt          time.Time  `json:"created_at"`
+	LastUsedAt         time.Time  `json:"last_used_at"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+	RevokedAt          *time.Time `json:"-"`
+	Current            bool       `json:"current"` // Session of the token that listed it, not stored
+}
+
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Device describes where a login comes from.
+type Device struct {
+	User_agent string
+	Ip         string
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
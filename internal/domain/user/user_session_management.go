@@ -0,0 +1,140 @@
+package user
+
+import (
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"strings"
+	"time"
+)
+
+// issueTokens opens a new session for the device and returns its tokens.
+func (u *userService) issueTokens(user User, device Device) (auth.TokenPair, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	claims := user.Claims()
+	claims.SessionID = sessionID
+	tokens, err := auth.CreateTokenPair(claims)
+	if err != nil {
+		log.Printf("error creating tokens for user %s: %v", user.Email, err)
+		return auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	if err := u.openSession(sessionID, user, hashToken(tokens.Refresh_token), sessionExpiration(), device); err != nil {
+		return auth.TokenPair{}, err
+	}
+
+	return tokens, nil
+}
+
+// issuePOSToken opens a short session without refresh token, limited to POS
+// actions. It is revoked like any other session.
+func (u *userService) issuePOSToken(user User, device Device) (auth.TokenPair, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	claims := user.Claims()
+	claims.SessionID = sessionID
+	claims.Scope = auth.ScopePOS
+	expiration := time.Duration(config.Envs.POS_TOKEN_EXPIRE) * time.Second
+	token, err := auth.CreateJWT(claims, auth.AccessToken, expiration)
+	if err != nil {
+		log.Printf("error creating POS token for user %s: %v", user.Email, err)
+		return auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	// No refresh token is issued, a random hash makes the session unrefreshable
+	unusable, err := newRandomToken()
+	if err != nil {
+		return auth.TokenPair{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	if err := u.openSession(sessionID, user, hashToken(unusable), time.Now().Add(expiration), device); err != nil {
+		return auth.TokenPair{}, err
+	}
+
+	return auth.TokenPair{Token: token, Expires_in: config.Envs.POS_TOKEN_EXPIRE}, nil
+}
+
+func (u *userService) openSession(sessionID string, user User, refreshTokenHash string, expiresAt time.Time, device Device) error {
+	device = device.truncated()
+	now := time.Now()
+	return u.sessions.Save(Session{
+		ID:                 sessionID,
+		User_id:            user.ID,
+		Refresh_token_hash: refreshTokenHash,
+		User_agent:         device.User_agent,
+		Ip:                 device.Ip,
+		CreatedAt:          now,
+		LastUsedAt:         now,
+		ExpiresAt:          expiresAt,
+	})
+}
+
+// Logout revokes the session of the current token.
+func (u *userService) Logout(sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return u.sessions.Revoke(sessionID)
+}
+
+// LogoutAll revokes every session of the user, e.g. a lost tablet or a
+// dismissed employee.
+func (u *userService) LogoutAll(userID int) (int, error) {
+	revoked, err := u.sessions.RevokeAll(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("%d sessions of user %d revoked", revoked, userID)
+	return revoked, nil
+}
+
+func (u *userService) ListSessions(userID int, currentSessionID string) ([]Session, error) {
+	sessions, err := u.sessions.FindActive(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// IsSessionActive is checked on every authenticated request. Errors count as
+// inactive, a revoked session must never get through.
+func (u *userService) IsSessionActive(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	session, err := u.sessions.FindByID(sessionID)
+	if err != nil {
+		log.Printf("error checking session %s: %v", sessionID, err)
+		return false
+	}
+	return session.IsActive(time.Now())
+}
+
+func sessionExpiration() time.Time {
+	return time.Now().Add(time.Duration(config.Envs.JWT_REFRESH_EXPIRE) * time.Second)
+}
+
+// truncated fits the device in the session columns.
+func (d Device) truncated() Device {
+	if len(d.User_agent) > 255 {
+		d.User_agent = strings.ToValidUTF8(d.User_agent[:255], "")
+	}
+	if len(d.Ip) > 45 {
+		d.Ip = d.Ip[:45]
+	}
+	return d
+}
@@ -0,0 +1,140 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+type SessionRepository interface {
+	Save(session Session) error
+	FindByID(id string) (Session, error)
+	// FindActive returns the user's sessions that are neither revoked nor expired.
+	FindActive(userID int) ([]Session, error)
+	// Rotate replaces the refresh token hash if it still is oldHash. It returns
+	// false when the token was already rotated, i.e. a refresh token was reused.
+	Rotate(id string, oldHash string, newHash string, device Device, expiresAt time.Time) (bool, error)
+	Revoke(id string) error
+	// RevokeAll returns how many sessions were revoked.
+	RevokeAll(userID int) (int, error)
+	// RevokeOthers revokes all sessions of the user but keepID.
+	RevokeOthers(userID int, keepID string) error
+}
+
+type sessionRepository struct {
+	*sql.DB
+}
+
+const sessionColumns = "id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at"
+
+func scanSession(scan func(dest ...any) error) (Session, error) {
+	var session Session
+	var revokedAt sql.NullTime
+	err := scan(&session.ID, &session.User_id, &session.Refresh_token_hash, &session.User_agent, &session.Ip, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt)
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, err
+}
+
+func (s *sessionRepository) Save(session Session) error {
+	query := "INSERT INTO user_sessions (id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+
+	_, err := s.DB.Exec(query, session.ID, session.User_id, session.Refresh_token_hash, session.User_agent, session.Ip, session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		log.Printf("error saving session of user %d: %v", session.User_id, err)
+		return exceptions.FromDatabaseError(err, "session")
+	}
+	return nil
+}
+
+func (s *sessionRepository) FindByID(id string) (Session, error) {
+	session, err := scanSession(s.DB.QueryRow("SELECT "+sessionColumns+" FROM user_sessions WHERE id = ?", id).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Session{}, exceptions.NewEntityNotFound("session", id)
+		}
+		log.Printf("error finding session %s: %v", id, err)
+		return Session{}, exceptions.FromDatabaseError(err, "session")
+	}
+	return session, nil
+}
+
+func (s *sessionRepository) FindActive(userID int) ([]Session, error) {
+	query := "SELECT " + sessionColumns + " FROM user_sessions WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY last_used_at DESC"
+
+	rows, err := s.DB.Query(query, userID, time.Now())
+	if err != nil {
+		log.Printf("error listing sessions of user %d: %v", userID, err)
+		return nil, exceptions.FromDatabaseError(err, "session")
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		session, err := scanSession(rows.Scan)
+		if err != nil {
+			return nil, exceptions.FromDatabaseError(err, "session")
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, exceptions.FromDatabaseError(err, "session")
+	}
+
+	return sessions, nil
+}
+
+func (s *sessionRepository) Rotate(id string, oldHash string, newHash string, device Device, expiresAt time.Time) (bool, error) {
+	query := `UPDATE user_sessions SET refresh_token_hash = ?, user_agent = ?, ip = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL`
+
+	result, err := s.DB.Exec(query, newHash, device.User_agent, device.Ip, time.Now(), expiresAt, id, oldHash)
+	if err != nil {
+		log.Printf("error rotating session %s: %v", id, err)
+		return false, exceptions.FromDatabaseError(err, "session")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "session")
+	}
+	return affected == 1, nil
+}
+
+func (s *sessionRepository) Revoke(id string) error {
+	if _, err := s.DB.Exec("UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id); err != nil {
+		log.Printf("error revoking session %s: %v", id, err)
+		return exceptions.FromDatabaseError(err, "session")
+	}
+	return nil
+}
+
+func (s *sessionRepository) RevokeAll(userID int) (int, error) {
+	result, err := s.DB.Exec("UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now(), userID)
+	if err != nil {
+		log.Printf("error revoking sessions of user %d: %v", userID, err)
+		return 0, exceptions.FromDatabaseError(err, "session")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, exceptions.FromDatabaseError(err, "session")
+	}
+	return int(affected), nil
+}
+
+func (s *sessionRepository) RevokeOthers(userID int, keepID string) error {
+	_, err := s.DB.Exec("UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND id <> ? AND revoked_at IS NULL", time.Now(), userID, keepID)
+	if err != nil {
+		log.Printf("error revoking other sessions of user %d: %v", userID, err)
+		return exceptions.FromDatabaseError(err, "session")
+	}
+	return nil
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db}
+}
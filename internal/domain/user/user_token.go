@@ -0,0 +1,32 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// UserToken is a single-use token sent to the user, e.g. in a password reset
+// link. Only the hash of the token is stored.
+type UserToken struct {
+	ID         int        `json:"id"`
+	User_id    int        `json:"user_id"`
+	Purpose    string     `json:"purpose"`
+	Token_hash string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
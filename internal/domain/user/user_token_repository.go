@@ -0,0 +1,102 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"log"
+	"time"
+)
+
+type UserTokenRepository interface {
+	Save(token UserToken) (UserToken, error)
+	// FindValid returns an unused, unexpired token with the given hash and purpose.
+	FindValid(tokenHash string, purpose string) (UserToken, error)
+	// MarkUsed returns false when the token was already used by a concurrent request.
+	MarkUsed(id int) (bool, error)
+	InvalidateAll(userID int, purpose string) error
+	// FindLatest returns the most recently issued token, used or not.
+	FindLatest(userID int, purpose string) (UserToken, error)
+}
+
+type userTokenRepository struct {
+	*sql.DB
+}
+
+func (u *userTokenRepository) Save(token UserToken) (UserToken, error) {
+	query := "INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)"
+
+	result, err := u.DB.Exec(query, token.User_id, token.Purpose, token.Token_hash, token.ExpiresAt)
+	if err != nil {
+		log.Printf("error saving %s token for user %d: %v", token.Purpose, token.User_id, err)
+		return UserToken{}, exceptions.FromDatabaseError(err, "token")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return UserToken{}, exceptions.FromDatabaseError(err, "token")
+	}
+
+	token.ID = int(id)
+	return token, nil
+}
+
+func (u *userTokenRepository) FindValid(tokenHash string, purpose string) (UserToken, error) {
+	query := `SELECT id, user_id, purpose, token_hash, expires_at, created_at FROM user_tokens
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?`
+
+	var token UserToken
+	err := u.DB.QueryRow(query, tokenHash, purpose, time.Now()).Scan(&token.ID, &token.User_id, &token.Purpose, &token.Token_hash, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserToken{}, exceptions.NewEntityNotFound("token", nil)
+		}
+		log.Printf("error finding %s token: %v", purpose, err)
+		return UserToken{}, exceptions.FromDatabaseError(err, "token")
+	}
+
+	return token, nil
+}
+
+func (u *userTokenRepository) MarkUsed(id int) (bool, error) {
+	result, err := u.DB.Exec("UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", time.Now(), id)
+	if err != nil {
+		log.Printf("error marking token %d as used: %v", id, err)
+		return false, exceptions.FromDatabaseError(err, "token")
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, exceptions.FromDatabaseError(err, "token")
+	}
+	return affected == 1, nil
+}
+
+func (u *userTokenRepository) InvalidateAll(userID int, purpose string) error {
+	_, err := u.DB.Exec("UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL", time.Now(), userID, purpose)
+	if err != nil {
+		log.Printf("error invalidating %s tokens of user %d: %v", purpose, userID, err)
+		return exceptions.FromDatabaseError(err, "token")
+	}
+	return nil
+}
+
+func (u *userTokenRepository) FindLatest(userID int, purpose string) (UserToken, error) {
+	query := `SELECT id, user_id, purpose, token_hash, expires_at, created_at FROM user_tokens
+		WHERE user_id = ? AND purpose = ? ORDER BY created_at DESC, id DESC LIMIT 1`
+
+	var token UserToken
+	err := u.DB.QueryRow(query, userID, purpose).Scan(&token.ID, &token.User_id, &token.Purpose, &token.Token_hash, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserToken{}, exceptions.NewEntityNotFound("token", nil)
+		}
+		return UserToken{}, exceptions.FromDatabaseError(err, "token")
+	}
+
+	return token, nil
+}
+
+func NewUserTokenRepository(db *sql.DB) UserTokenRepository {
+	return &userTokenRepository{db}
+}
@@ -0,0 +1,284 @@
+package user
+
+import (
+	"crypto/rand"
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/notifier"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// Ambiguous characters (0/O, 1/I/L) are left out, codes are typed by hand
+const recoveryCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+type TwoFactorSetup struct {
+	Secret      string `json:"secret"`
+	Otpauth_uri string `json:"otpauth_uri"`
+}
+
+func requiresTwoFactor(role string) bool {
+	for _, required := range strings.Split(config.Envs.TWO_FACTOR_REQUIRED_ROLES, ",") {
+		if strings.TrimSpace(required) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorChallenge returns the error that makes the client finish the login
+// with POST /api/auth/2fa/verify, or enroll first when the role requires it.
+func (u *userService) twoFactorChallenge(user User) error {
+	expiration := time.Duration(config.Envs.TWO_FACTOR_CHALLENGE_EXPIRE) * time.Second
+	challenge, err := auth.CreateJWT(user.Claims(), auth.ChallengeToken, expiration)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	log.Printf("user %s must complete two-factor authentication", user.Email)
+	return exceptions.NewTwoFactorRequiredError(challenge, !user.TwoFactorEnabled())
+}
+
+func (u *userService) VerifyTwoFactor(challengeToken string, code string, device Device) (User, auth.TokenPair, error) {
+	claims, err := auth.ParseJWT(challengeToken, auth.ChallengeToken)
+	if err != nil {
+		log.Printf("invalid challenge token: %v", err)
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid or expired challenge token")
+	}
+
+	user, err := u.UserRepository.FindByID(claims.UserID)
+	if err != nil {
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("invalid or expired challenge token")
+	}
+
+	if !user.TwoFactorEnabled() {
+		return User{}, auth.TokenPair{}, exceptions.NewUnauthorizedError("two-factor authentication is not set up")
+	}
+	if !user.IsActive() {
+		return User{}, auth.TokenPair{}, exceptions.NewAccountDeactivatedError()
+	}
+
+	if err := u.checkSecondFactor(user, code); err != nil {
+		return User{}, auth.TokenPair{}, err
+	}
+
+	tokens, err := u.issueTokens(user, device)
+	if err != nil {
+		return User{}, auth.TokenPair{}, err
+	}
+
+	log.Printf("user %s logged in with two-factor authentication", user.Email)
+	return user, tokens, nil
+}
+
+func (u *userService) SetupTwoFactor(userID int) (TwoFactorSetup, error) {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	if user.TwoFactorEnabled() {
+		return TwoFactorSetup{}, exceptions.NewConflictError("two_factor", "two-factor authentication is already enabled")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return TwoFactorSetup{}, exceptions.NewInternalServerError(err.Error())
+	}
+
+	if err := u.UserRepository.SetTOTPSecret(user.ID, secret); err != nil {
+		return TwoFactorSetup{}, err
+	}
+
+	return TwoFactorSetup{
+		Secret:      secret,
+		Otpauth_uri: auth.TOTPURI(config.Envs.TWO_FACTOR_ISSUER, user.Email, secret),
+	}, nil
+}
+
+// EnableTwoFactor confirms the secret from SetupTwoFactor with a code from the
+// authenticator. When the user enrolled with a login challenge, the login is
+// completed and tokens are returned too.
+func (u *userService) EnableTwoFactor(claims auth.Claims, code string, device Device) ([]string, auth.TokenPair, error) {
+	user, err := u.UserRepository.FindByID(claims.UserID)
+	if err != nil {
+		return nil, auth.TokenPair{}, err
+	}
+
+	if !user.IsActive() {
+		return nil, auth.TokenPair{}, exceptions.NewAccountDeactivatedError()
+	}
+	if user.TwoFactorEnabled() {
+		return nil, auth.TokenPair{}, exceptions.NewConflictError("two_factor", "two-factor authentication is already enabled")
+	}
+	if user.Totp_secret == "" {
+		return nil, auth.TokenPair{}, exceptions.NewValidationError("two_factor", "Start the two-factor setup first")
+	}
+
+	if err := u.checkTOTP(user, code); err != nil {
+		return nil, auth.TokenPair{}, exceptions.NewValidationError("code", "The code is invalid, check the time of your device")
+	}
+
+	if err := u.UserRepository.EnableTOTP(user.ID, time.Now()); err != nil {
+		return nil, auth.TokenPair{}, err
+	}
+
+	codes, err := u.replaceRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, auth.TokenPair{}, err
+	}
+
+	var tokens auth.TokenPair
+	if claims.Type == auth.ChallengeToken {
+		if tokens, err = u.issueTokens(user, device); err != nil {
+			return nil, auth.TokenPair{}, err
+		}
+	}
+
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Two-factor authentication enabled",
+		Body:    fmt.Sprintf("Hi %s,\n\nTwo-factor authentication was enabled on your account. If it wasn't you, contact an administrator.", user.First_name),
+	})
+
+	log.Printf("two-factor authentication enabled for user %s", user.Email)
+	return codes, tokens, nil
+}
+
+func (u *userService) DisableTwoFactor(userID int, password string, code string) error {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if requiresTwoFactor(user.Role) {
+		return exceptions.NewForbiddenError("two-factor authentication is required for role " + user.Role)
+	}
+	if !user.TwoFactorEnabled() {
+		return exceptions.NewConflictError("two_factor", "two-factor authentication is not enabled")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return exceptions.NewUnauthorizedError("invalid password")
+	}
+	if err := u.checkSecondFactor(user, code); err != nil {
+		return err
+	}
+
+	if err := u.UserRepository.DisableTOTP(user.ID); err != nil {
+		return err
+	}
+	if err := u.recoveryCodes.DeleteAll(user.ID); err != nil {
+		return err
+	}
+
+	u.notify(notifier.Message{
+		To:      user.Email,
+		Subject: "Two-factor authentication disabled",
+		Body:    fmt.Sprintf("Hi %s,\n\nTwo-factor authentication was disabled on your account. If it wasn't you, reset your password and contact an administrator.", user.First_name),
+	})
+
+	log.Printf("two-factor authentication disabled for user %s", user.Email)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, used or not.
+func (u *userService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	user, err := u.UserRepository.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabled() {
+		return nil, exceptions.NewConflictError("two_factor", "two-factor authentication is not enabled")
+	}
+	if err := u.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	return u.replaceRecoveryCodes(user.ID)
+}
+
+// checkSecondFactor accepts a TOTP code or, failing that, an unused recovery code.
+func (u *userService) checkSecondFactor(user User, code string) error {
+	if err := u.checkTOTP(user, code); err == nil {
+		return nil
+	}
+
+	used, err := u.recoveryCodes.Use(user.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		log.Printf("invalid two-factor code for user %s", user.Email)
+		return exceptions.NewUnauthorizedError("invalid two-factor code")
+	}
+
+	log.Printf("user %s used a recovery code", user.Email)
+	return nil
+}
+
+func (u *userService) checkTOTP(user User, code string) error {
+	step, ok := auth.ValidateTOTP(user.Totp_secret, code, time.Now(), user.Totp_last_step)
+	if !ok {
+		return exceptions.NewUnauthorizedError("invalid two-factor code")
+	}
+
+	// A concurrent request may have used the same code
+	used, err := u.UserRepository.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return exceptions.NewUnauthorizedError("invalid two-factor code")
+	}
+	return nil
+}
+
+func (u *userService) replaceRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, exceptions.NewInternalServerError(err.Error())
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := u.recoveryCodes.ReplaceAll(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code like "ABCDE-FGHJK".
+func newRecoveryCode() (string, error) {
+	code := make([]byte, 0, 11)
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code = append(code, recoveryCodeAlphabet[n.Int64()])
+	}
+	return string(code), nil
+}
+
+var recoveryCodeSeparators = strings.NewReplacer("-", "", " ", "")
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(recoveryCodeSeparators.Replace(code))
+}
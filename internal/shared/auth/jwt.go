@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-restaurant-management/config"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AccessToken    = "access"
+	RefreshToken   = "refresh"
+	ChallengeToken = "2fa" // Password checked, waiting for the second factor
+)
+
+// ScopePOS limits an access token to POS actions, see WithPOSAuth.
+const ScopePOS = "pos"
+
+type Claims struct {
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	Scope     string `json:"scope,omitempty"` // Empty for full access
+	Type      string `json:"type"`
+
+	// Set for requests authenticated with an API key, never in tokens
+	APIKeyID int      `json:"-"`
+	Scopes   []string `json:"-"`
+
+	jwt.RegisteredClaims
+}
+
+type TokenPair struct {
+	Token         string `json:"token"`
+	Refresh_token string `json:"refresh_token"`
+	Expires_in    int64  `json:"expires_in"`
+}
+
+// CreateTokenPair issues an access and a refresh token for the user
+// described by claims. Only the application claims need to be set.
+func CreateTokenPair(claims Claims) (TokenPair, error) {
+	token, err := CreateJWT(claims, AccessToken, time.Duration(config.Envs.JWT_EXPIRE)*time.Second)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, err := CreateJWT(claims, RefreshToken, time.Duration(config.Envs.JWT_REFRESH_EXPIRE)*time.Second)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		Token:         token,
+		Refresh_token: refreshToken,
+		Expires_in:    config.Envs.JWT_EXPIRE,
+	}, nil
+}
+
+func CreateJWT(claims Claims, tokenType string, expiration time.Duration) (string, error) {
+	now := time.Now()
+	claims.Type = tokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        newTokenID(),
+		Subject:   strconv.Itoa(claims.UserID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.Envs.JWT_SECRET))
+}
+
+// ParseJWT validates the signature, expiration and type of a token.
+func ParseJWT(tokenString string, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(config.Envs.JWT_SECRET), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("expected %s token, got %s", tokenType, claims.Type)
+	}
+
+	return claims, nil
+}
+
+func newTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
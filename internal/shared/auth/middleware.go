@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type contextKey string
+
+const claimsKey contextKey = "claims"
+
+// SessionChecker reports whether the session an access token belongs to is
+// still active, so revoked sessions are refused before their tokens expire.
+type SessionChecker interface {
+	IsSessionActive(sessionID string) bool
+}
+
+// APIKeyAuthenticator validates an X-API-Key header sent from ip and returns
+// the claims of the key.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string, ip string) (*Claims, error)
+}
+
+// APIKeyAuthenticatorFunc lets a function be used as an APIKeyAuthenticator.
+type APIKeyAuthenticatorFunc func(key string, ip string) (*Claims, error)
+
+func (f APIKeyAuthenticatorFunc) AuthenticateAPIKey(key string, ip string) (*Claims, error) {
+	return f(key, ip)
+}
+
+// Authenticator holds what the authentication middlewares check tokens
+// against. Access tokens are refused when it has no SessionChecker, and API
+// keys when it has no APIKeyAuthenticator.
+type Authenticator struct {
+	sessions SessionChecker
+	apiKeys  APIKeyAuthenticator
+}
+
+func NewAuthenticator(sessions SessionChecker, apiKeys APIKeyAuthenticator) *Authenticator {
+	return &Authenticator{sessions: sessions, apiKeys: apiKeys}
+}
+
+func (a *Authenticator) WithJwtAuth(next http.HandlerFunc) http.HandlerFunc {
+	return a.withAuth([]string{AccessToken}, false)(next)
+}
+
+// WithPOSAuth is like WithJwtAuth but also accepts the POS scoped tokens of
+// staff PIN logins. Use it only on routes for actions taken at a terminal.
+func (a *Authenticator) WithPOSAuth(next http.HandlerFunc) http.HandlerFunc {
+	return a.withAuth([]string{AccessToken}, true)(next)
+}
+
+// WithTokenTypes authenticates with a bearer token of any of the given types,
+// e.g. to let users finish a login challenge. Handlers can check Claims.Type.
+func (a *Authenticator) WithTokenTypes(tokenTypes ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return a.withAuth(tokenTypes, false)
+}
+
+// WithScope authenticates users like WithJwtAuth, and also API keys that were
+// granted the scope. Users are authorized by role, which API keys don't have,
+// so only check the role of claims without an APIKeyID after it.
+func (a *Authenticator) WithScope(scope string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		withJwt := a.WithJwtAuth(next)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				withJwt(w, r)
+				return
+			}
+
+			if a.apiKeys == nil {
+				utils.WriteError(w, exceptions.NewUnauthorizedError("api keys are not accepted"))
+				return
+			}
+
+			claims, err := a.apiKeys.AuthenticateAPIKey(key, utils.GetClientIP(r))
+			if err != nil {
+				utils.WriteError(w, err)
+				return
+			}
+
+			if !slices.Contains(claims.Scopes, scope) {
+				utils.WriteError(w, exceptions.NewForbiddenError("api key lacks the "+scope+" scope"))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsKey, claims)
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// ValidateAccessToken parses an access token and checks that its session is
+// still active.
+func (a *Authenticator) ValidateAccessToken(tokenString string) (*Claims, error) {
+	claims, err := ParseJWT(tokenString, AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkSession(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *Authenticator) checkSession(claims *Claims) error {
+	if claims.Type != AccessToken {
+		return nil
+	}
+	if a.sessions == nil {
+		return exceptions.NewUnauthorizedError("sessions can't be checked")
+	}
+	if !a.sessions.IsSessionActive(claims.SessionID) {
+		return exceptions.NewUnauthorizedError("session revoked, log in again")
+	}
+	return nil
+}
+
+func (a *Authenticator) withAuth(tokenTypes []string, allowPOS bool) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			tokenString := GetBearerToken(r)
+			if tokenString == "" {
+				utils.WriteError(w, exceptions.NewUnauthorizedError("missing bearer token"))
+				return
+			}
+
+			var claims *Claims
+			var err error
+			for _, tokenType := range tokenTypes {
+				if claims, err = ParseJWT(tokenString, tokenType); err == nil {
+					break
+				}
+			}
+			if err != nil {
+				log.Printf("invalid bearer token: %v", err)
+				utils.WriteError(w, exceptions.NewUnauthorizedError("invalid or expired token"))
+				return
+			}
+
+			if err := a.checkSession(claims); err != nil {
+				utils.WriteError(w, err)
+				return
+			}
+
+			if claims.Scope == ScopePOS && !allowPOS {
+				utils.WriteError(w, exceptions.NewForbiddenError("token is limited to POS actions"))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsKey, claims)
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// WithRole must run after WithJwtAuth.
+func WithRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r.Context())
+			if !ok {
+				utils.WriteError(w, exceptions.NewUnauthorizedError("missing authentication"))
+				return
+			}
+
+			if !slices.Contains(roles, claims.Role) {
+				utils.WriteError(w, exceptions.NewForbiddenError("role "+claims.Role+" is not allowed to access this resource"))
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// EmailVerifier reports whether the user verified their email. It is asked on
+// each request, tokens issued before the verification stay valid.
+type EmailVerifier interface {
+	IsEmailVerified(userID int) bool
+}
+
+// WithVerifiedEmail blocks customers whose email is not verified. Staff
+// accounts are created by managers and are exempt. It must run after
+// WithJwtAuth and is a no-op when REQUIRE_VERIFIED_EMAIL_FOR_ORDERS is
+// disabled.
+func WithVerifiedEmail(verifier EmailVerifier, customerRole string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r.Context())
+			if !ok {
+				utils.WriteError(w, exceptions.NewUnauthorizedError("missing authentication"))
+				return
+			}
+
+			if config.Envs.REQUIRE_VERIFIED_EMAIL_FOR_ORDERS && claims.Role == customerRole && !verifier.IsEmailVerified(claims.UserID) {
+				utils.WriteError(w, exceptions.NewEmailNotVerifiedError())
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func GetBearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func GetClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+func GetUserIDFromContext(ctx context.Context) int {
+	if claims, ok := GetClaimsFromContext(ctx); ok {
+		return claims.UserID
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithScope(t *testing.T) {
+	authenticator := NewAuthenticator(activeSessions{}, APIKeyAuthenticatorFunc(func(key string, ip string) (*Claims, error) {
+		if key == "rk_kiosk" {
+			return &Claims{APIKeyID: 1, Scopes: []string{"menus:read"}, Type: AccessToken}, nil
+		}
+		return nil, exceptions.NewUnauthorizedError("invalid api key")
+	}))
+	h := authenticator.WithScope("menus:read")(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ordersHandler := authenticator.WithScope("orders:write")(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	userToken, err := CreateJWT(Claims{UserID: 1, Role: "waiter"}, AccessToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		header  string
+		value   string
+		want    int
+	}{
+		{"should accept keys with the scope", h, "X-API-Key", "rk_kiosk", http.StatusOK},
+		{"should refuse keys without the scope", ordersHandler, "X-API-Key", "rk_kiosk", http.StatusForbidden},
+		{"should refuse unknown keys", h, "X-API-Key", "rk_unknown", http.StatusUnauthorized},
+		{"should accept users", ordersHandler, "Authorization", "Bearer " + userToken, http.StatusOK},
+		{"should refuse anonymous requests", h, "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/menus", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("expected %v, got %v: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+type activeSessions struct{}
+
+func (activeSessions) IsSessionActive(sessionID string) bool { return true }
+
+func TestWithJwtAuth(t *testing.T) {
+	token, err := CreateJWT(Claims{UserID: 1, Role: "waiter", SessionID: "tablet"}, AccessToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	tests := []struct {
+		name          string
+		authenticator *Authenticator
+		want          int
+	}{
+		{"should accept tokens of active sessions", NewAuthenticator(activeSessions{}, nil), http.StatusOK},
+		{"should refuse tokens when sessions can't be checked", NewAuthenticator(nil, nil), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/users/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rr := httptest.NewRecorder()
+			tt.authenticator.WithJwtAuth(ok)(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("expected %v, got %v: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+type verifiedUsers map[int]bool
+
+func (v verifiedUsers) IsEmailVerified(userID int) bool { return v[userID] }
+
+func TestWithVerifiedEmail(t *testing.T) {
+	h := WithVerifiedEmail(verifiedUsers{1: true}, "customer")(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   int
+	}{
+		{"should accept verified customers", Claims{UserID: 1, Role: "customer"}, http.StatusOK},
+		{"should refuse customers not verified yet", Claims{UserID: 2, Role: "customer"}, http.StatusForbidden},
+		{"should accept staff", Claims{UserID: 3, Role: "waiter"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/orders", nil)
+			req = req.WithContext(context.WithValue(req.Context(), claimsKey, &tt.claims))
+
+			rr := httptest.NewRecorder()
+			h(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("expected %v, got %v: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
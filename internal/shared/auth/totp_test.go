@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 test secret, codes truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	t.Run("should match the RFC 6238 test vectors", func(t *testing.T) {
+		tests := []struct {
+			unix int64
+			code string
+		}{
+			{59, "287082"},
+			{1111111109, "081804"},
+			{1234567890, "005924"},
+			{2000000000, "279037"},
+		}
+
+		for _, tt := range tests {
+			code, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.code {
+				t.Errorf("at %d: expected %s, got %s", tt.unix, tt.code, code)
+			}
+		}
+	})
+
+	t.Run("should accept adjacent steps only once", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+
+		step, ok := ValidateTOTP(secret, previous, now, 0)
+		if !ok || step != TOTPStep(now)-1 {
+			t.Fatalf("expected the previous step to be accepted, got %d %v", step, ok)
+		}
+		if _, ok := ValidateTOTP(secret, previous, now, step); ok {
+			t.Error("expected a used code to be rejected")
+		}
+
+		old, _ := TOTPCode(secret, TOTPStep(now)-2)
+		if _, ok := ValidateTOTP(secret, old, now, 0); ok {
+			t.Error("expected an old code to be rejected")
+		}
+	})
+
+	t.Run("should build an otpauth uri", func(t *testing.T) {
+		uri := TOTPURI("Restaurant", "ana@example.com", secret)
+		if !strings.HasPrefix(uri, "otpauth://totp/Restaurant:ana@example.com?") || !strings.Contains(uri, "secret="+secret) {
+			t.Errorf("unexpected uri %s", uri)
+		}
+	})
+}
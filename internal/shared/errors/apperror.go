@@ -10,6 +10,7 @@ type AppError struct {
 	Message string                 `json:"message"`
 	Details map[string]interface{} `json:"details,omitempty"`
 	Cause   error                  `json:"-"`
+	Headers map[string]string      `json:"-"` // Extra response headers, e.g. Retry-After
 }
 
 func (e *AppError) Error() string {
@@ -35,6 +36,16 @@ func (e *AppError) HTTPStatusCode() int {
 		return 403
 	case CONFLICT:
 		return 409
+	case PRECONDITION_FAILED:
+		return 412
+	case PAYLOAD_TOO_LARGE:
+		return 413
+	case UNSUPPORTED_MEDIA_TYPE:
+		return 415
+	case PRECONDITION_REQUIRED:
+		return 428
+	case TOO_MANY_REQUESTS:
+		return 429
 	case INTERNAL:
 		return 500
 	default:
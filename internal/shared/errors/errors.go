@@ -10,4 +10,10 @@ const (
 	FORBIDDEN    ErrorType = "FORBIDDEN"
 	CONFLICT     ErrorType = "CONFLICT"
 	INTERNAL     ErrorType = "INTERNAL"
+
+	TOO_MANY_REQUESTS      ErrorType = "TOO_MANY_REQUESTS"
+	PAYLOAD_TOO_LARGE      ErrorType = "PAYLOAD_TOO_LARGE"
+	UNSUPPORTED_MEDIA_TYPE ErrorType = "UNSUPPORTED_MEDIA_TYPE"
+	PRECONDITION_FAILED    ErrorType = "PRECONDITION_FAILED"
+	PRECONDITION_REQUIRED  ErrorType = "PRECONDITION_REQUIRED"
 )
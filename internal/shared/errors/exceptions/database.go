@@ -0,0 +1,131 @@
+package exceptions
+
+import (
+	stderrors "errors"
+	"fmt"
+	"go-restaurant-management/internal/shared/errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers we translate into typed errors.
+// See https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlErrDuplicateEntry     = 1062
+	mysqlErrRowIsReferenced    = 1451
+	mysqlErrNoReferencedRow    = 1452
+	mysqlErrDataTooLong        = 1406
+	mysqlErrBadNull            = 1048
+	mysqlErrOutOfRange         = 1264
+	mysqlErrTruncatedValue     = 1366
+	mysqlErrCheckConstraint    = 3819
+	mysqlErrNoDefaultForField  = 1364
+	mysqlErrRowIsReferencedOld = 1217
+	mysqlErrNoReferencedRowOld = 1216
+)
+
+var (
+	duplicateKeyPattern = regexp.MustCompile(`for key '([^']+)'`)
+	foreignKeyPattern   = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+	columnPattern       = regexp.MustCompile(`(?i)column '([^']+)'`)
+	fieldPattern        = regexp.MustCompile(`Field '([^']+)'`)
+	constraintPattern   = regexp.MustCompile(`constraint '([^']+)'`)
+)
+
+// FromDatabaseError translates errors returned by database/sql into AppErrors.
+// Constraint violations become CONFLICT or BAD_REQUEST errors carrying the
+// offending field, anything else becomes an INTERNAL error that keeps the
+// driver error as its cause without exposing it to clients.
+func FromDatabaseError(err error, entity string) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !stderrors.As(err, &mysqlErr) {
+		return newDatabaseError(entity, err)
+	}
+
+	var translated *errors.AppError
+	switch mysqlErr.Number {
+	case mysqlErrDuplicateEntry:
+		field := keyToField(match(duplicateKeyPattern, mysqlErr.Message))
+		translated = NewConflictError(field, fmt.Sprintf("%s with this %s already exists", entity, field))
+	case mysqlErrRowIsReferenced, mysqlErrRowIsReferencedOld:
+		field := match(foreignKeyPattern, mysqlErr.Message)
+		translated = NewConflictError(field, fmt.Sprintf("%s is still referenced by other resources", entity))
+	case mysqlErrNoReferencedRow, mysqlErrNoReferencedRowOld:
+		translated = NewInvalidReferenceError(match(foreignKeyPattern, mysqlErr.Message))
+	case mysqlErrDataTooLong:
+		field := match(columnPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The field %s is too long", field))
+	case mysqlErrBadNull:
+		field := match(columnPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The field %s is required", field))
+	case mysqlErrNoDefaultForField:
+		field := match(fieldPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The field %s is required", field))
+	case mysqlErrOutOfRange:
+		field := match(columnPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The field %s is out of range", field))
+	case mysqlErrTruncatedValue:
+		field := match(columnPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The field %s has an invalid value", field))
+	case mysqlErrCheckConstraint:
+		field := match(constraintPattern, mysqlErr.Message)
+		translated = NewValidationError(field, fmt.Sprintf("The constraint %s was violated", field))
+	default:
+		return newDatabaseError(entity, err)
+	}
+
+	translated.Cause = err
+	return translated
+}
+
+func NewInvalidReferenceError(field string) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.BAD_REQUEST,
+		Code:    "INVALID_REFERENCE",
+		Message: "Referenced resource does not exist",
+		Details: map[string]interface{}{
+			"field":  field,
+			"reason": fmt.Sprintf("The resource referenced by %s does not exist", field),
+		},
+	}
+}
+
+func newDatabaseError(entity string, cause error) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.INTERNAL,
+		Code:    "DATABASE_ERROR",
+		Message: "Internal Server Error",
+		Details: map[string]interface{}{
+			"entity": entity,
+			"reason": "unexpected database error",
+		},
+		Cause: cause,
+	}
+}
+
+func match(pattern *regexp.Regexp, message string) string {
+	if m := pattern.FindStringSubmatch(message); len(m) == 2 {
+		return m[1]
+	}
+	return "unknown"
+}
+
+// keyToField strips the table prefix MySQL 8 adds to key names ("users.email").
+// Unique indexes are expected to be named after the column they guard.
+func keyToField(key string) string {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[i+1:]
+	}
+	return key
+}
@@ -0,0 +1,132 @@
+package exceptions
+
+import (
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"go-restaurant-management/internal/shared/errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestFromDatabaseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantType   errors.ErrorType
+		wantCode   string
+		wantField  interface{}
+		wantStatus int
+	}{
+		{
+			name:       "duplicate entry with table prefixed key",
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'john@example.com' for key 'users.email'"},
+			wantType:   errors.CONFLICT,
+			wantCode:   "CONFLICT_ERROR",
+			wantField:  "email",
+			wantStatus: 409,
+		},
+		{
+			name:       "duplicate entry with plain key",
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '123' for key 'phone'"},
+			wantType:   errors.CONFLICT,
+			wantCode:   "CONFLICT_ERROR",
+			wantField:  "phone",
+			wantStatus: 409,
+		},
+		{
+			name:       "foreign key violation on insert",
+			err:        &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`orders`, CONSTRAINT `fk_orders_table` FOREIGN KEY (`table_id`) REFERENCES `tables` (`id`))"},
+			wantType:   errors.BAD_REQUEST,
+			wantCode:   "INVALID_REFERENCE",
+			wantField:  "table_id",
+			wantStatus: 400,
+		},
+		{
+			name:       "foreign key violation on delete",
+			err:        &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails (`db`.`orders`, CONSTRAINT `fk_orders_table` FOREIGN KEY (`table_id`) REFERENCES `tables` (`id`))"},
+			wantType:   errors.CONFLICT,
+			wantCode:   "CONFLICT_ERROR",
+			wantField:  "table_id",
+			wantStatus: 409,
+		},
+		{
+			name:       "data too long",
+			err:        &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'phone' at row 1"},
+			wantType:   errors.BAD_REQUEST,
+			wantCode:   "VALIDATION_ERROR",
+			wantField:  "phone",
+			wantStatus: 400,
+		},
+		{
+			name:       "null column",
+			err:        &mysql.MySQLError{Number: 1048, Message: "Column 'first_name' cannot be null"},
+			wantType:   errors.BAD_REQUEST,
+			wantCode:   "VALIDATION_ERROR",
+			wantField:  "first_name",
+			wantStatus: 400,
+		},
+		{
+			name:       "wrapped driver error",
+			err:        fmt.Errorf("saving: %w", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'x' for key 'users.email'"}),
+			wantType:   errors.CONFLICT,
+			wantCode:   "CONFLICT_ERROR",
+			wantField:  "email",
+			wantStatus: 409,
+		},
+		{
+			name:       "unknown driver error",
+			err:        &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"},
+			wantType:   errors.INTERNAL,
+			wantCode:   "DATABASE_ERROR",
+			wantStatus: 500,
+		},
+		{
+			name:       "non driver error",
+			err:        sql.ErrConnDone,
+			wantType:   errors.INTERNAL,
+			wantCode:   "DATABASE_ERROR",
+			wantStatus: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromDatabaseError(tt.err, "user")
+
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) {
+				t.Fatalf("expected *errors.AppError, got %T", err)
+			}
+			if appErr.Type != tt.wantType {
+				t.Errorf("unexpected type: got %v want %v", appErr.Type, tt.wantType)
+			}
+			if appErr.Code != tt.wantCode {
+				t.Errorf("unexpected code: got %v want %v", appErr.Code, tt.wantCode)
+			}
+			if appErr.HTTPStatusCode() != tt.wantStatus {
+				t.Errorf("unexpected status: got %v want %v", appErr.HTTPStatusCode(), tt.wantStatus)
+			}
+			if tt.wantField != nil && appErr.Details["field"] != tt.wantField {
+				t.Errorf("unexpected field: got %v want %v", appErr.Details["field"], tt.wantField)
+			}
+			if !stderrors.Is(err, tt.err) && !stderrors.Is(appErr.Cause, tt.err) {
+				t.Errorf("expected cause to be preserved")
+			}
+		})
+	}
+
+	t.Run("should return nil for nil error", func(t *testing.T) {
+		if err := FromDatabaseError(nil, "user"); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should pass app errors through", func(t *testing.T) {
+		original := NewEntityNotFound("user", 1)
+		if err := FromDatabaseError(original, "user"); err != original {
+			t.Errorf("expected the original error, got %v", err)
+		}
+	})
+}
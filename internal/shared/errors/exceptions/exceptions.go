@@ -1,8 +1,16 @@
 package exceptions
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"go-restaurant-management/internal/shared/errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func NewEntityNotFound(entity string, id interface{}) *errors.AppError {
@@ -29,15 +37,142 @@ func NewValidationError(field string, reason string) *errors.AppError {
 	}
 }
 
+// ErrMultipleJSONValues is the cause of bodies with data after the JSON value.
+var ErrMultipleJSONValues = stderrors.New("request body must contain a single JSON value")
+
+// NewInvalidJSONError describes what is wrong with the body and, when the
+// decoder tells, the field and byte offset where it went wrong.
 func NewInvalidJSONError(cause error) *errors.AppError {
+	details := map[string]interface{}{
+		"reason": "The request body contains invalid JSON",
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case stderrors.As(cause, &syntaxErr):
+		details["reason"] = fmt.Sprintf("The request body has malformed JSON at byte %d", syntaxErr.Offset)
+		details["offset"] = syntaxErr.Offset
+	case stderrors.As(cause, &typeErr):
+		details["reason"] = fmt.Sprintf("The field %s must be %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind()))
+		details["field"] = typeErr.Field
+		details["offset"] = typeErr.Offset
+	case stderrors.Is(cause, io.EOF):
+		details["reason"] = "The request body is empty"
+	case stderrors.Is(cause, io.ErrUnexpectedEOF):
+		details["reason"] = "The request body has malformed JSON, it ends too early"
+	case stderrors.Is(cause, ErrMultipleJSONValues):
+		details["reason"] = "The request body must contain a single JSON value"
+	case cause != nil && strings.HasPrefix(cause.Error(), "json: unknown field "):
+		// The decoder has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(cause.Error(), "json: unknown field "), `"`)
+		details["reason"] = fmt.Sprintf("The field %s is not allowed", field)
+		details["field"] = field
+	}
+
 	return &errors.AppError{
 		Type:    errors.BAD_REQUEST,
 		Code:    "INVALID_JSON",
 		Message: "Invalid JSON format",
+		Details: details,
+		Cause:   cause,
+	}
+}
+
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "of type " + kind.String()
+}
+
+// NewPreconditionFailedError rejects a write based on an outdated version of
+// the entity, the client should fetch it again and reapply its changes.
+func NewPreconditionFailedError(entity string) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.PRECONDITION_FAILED,
+		Code:    "PRECONDITION_FAILED",
+		Message: "Resource was modified",
+		Details: map[string]interface{}{
+			"reason": fmt.Sprintf("The %s was changed by someone else, reload it and try again", entity),
+			"entity": entity,
+		},
+	}
+}
+
+func NewPreconditionRequiredError() *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.PRECONDITION_REQUIRED,
+		Code:    "PRECONDITION_REQUIRED",
+		Message: "Precondition required",
 		Details: map[string]interface{}{
-			"reason": "The request body contains invalid JSON",
+			"reason": "Send the ETag of the resource in the If-Match header",
+		},
+	}
+}
+
+func NewPayloadTooLargeError(maxBytes int64) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.PAYLOAD_TOO_LARGE,
+		Code:    "PAYLOAD_TOO_LARGE",
+		Message: "Request body too large",
+		Details: map[string]interface{}{
+			"reason":    fmt.Sprintf("The request body must be at most %d bytes", maxBytes),
+			"max_bytes": maxBytes,
+		},
+	}
+}
+
+func NewUnsupportedMediaTypeError(contentType string, supported string) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.UNSUPPORTED_MEDIA_TYPE,
+		Code:    "UNSUPPORTED_MEDIA_TYPE",
+		Message: "Unsupported media type",
+		Details: map[string]interface{}{
+			"reason":       fmt.Sprintf("The content type %s is not supported, use %s", contentType, supported),
+			"content_type": contentType,
+		},
+	}
+}
+
+func NewIdempotencyKeyReusedError() *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.CONFLICT,
+		Code:    "IDEMPOTENCY_KEY_REUSED",
+		Message: "Idempotency key reused",
+		Details: map[string]interface{}{
+			"reason": "The Idempotency-Key was already used for a different request, send a new key",
+		},
+	}
+}
+
+// NewIdempotencyKeyInUseError is returned while the first request with the
+// key is still running.
+func NewIdempotencyKeyInUseError(retryAfter time.Duration) *errors.AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return &errors.AppError{
+		Type:    errors.CONFLICT,
+		Code:    "IDEMPOTENCY_KEY_IN_USE",
+		Message: "Request already in progress",
+		Details: map[string]interface{}{
+			"reason":              "A request with this Idempotency-Key is still being processed, retry later",
+			"retry_after_seconds": seconds,
+		},
+		Headers: map[string]string{
+			"Retry-After": strconv.Itoa(seconds),
 		},
-		Cause: cause,
 	}
 }
 
@@ -110,3 +245,71 @@ func NewMultipleValidationErrors(errors_ []map[string]interface{}) *errors.AppEr
 		},
 	}
 }
+
+func NewTooManyRequestsError(reason string, retryAfter time.Duration) *errors.AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return &errors.AppError{
+		Type:    errors.TOO_MANY_REQUESTS,
+		Code:    "TOO_MANY_REQUESTS",
+		Message: "Too many requests",
+		Details: map[string]interface{}{
+			"reason":              reason,
+			"retry_after_seconds": seconds,
+		},
+		Headers: map[string]string{
+			"Retry-After": strconv.Itoa(seconds),
+		},
+	}
+}
+
+func NewForbiddenError(reason string) *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.FORBIDDEN,
+		Code:    "FORBIDDEN",
+		Message: "Access denied",
+		Details: map[string]interface{}{
+			"reason": reason,
+		},
+	}
+}
+
+func NewEmailNotVerifiedError() *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.FORBIDDEN,
+		Code:    "EMAIL_NOT_VERIFIED",
+		Message: "Email not verified",
+		Details: map[string]interface{}{
+			"reason": "Confirm your email address before continuing",
+		},
+	}
+}
+
+func NewAccountDeactivatedError() *errors.AppError {
+	return &errors.AppError{
+		Type:    errors.FORBIDDEN,
+		Code:    "ACCOUNT_DEACTIVATED",
+		Message: "Account deactivated",
+		Details: map[string]interface{}{
+			"reason": "This account was deactivated, contact the restaurant manager",
+		},
+	}
+}
+
+// NewTwoFactorRequiredError asks the client to finish the login with a second
+// factor, or to enroll one first, using the challenge token.
+func NewTwoFactorRequiredError(challengeToken string, enrollment bool) *errors.AppError {
+	code, reason := "TWO_FACTOR_REQUIRED", "Enter the code from your authenticator app"
+	if enrollment {
+		code, reason = "TWO_FACTOR_ENROLLMENT_REQUIRED", "Your role requires two-factor authentication, set it up to continue"
+	}
+
+	return &errors.AppError{
+		Type:    errors.UNAUTHORIZED,
+		Code:    code,
+		Message: "Two-factor authentication required",
+		Details: map[string]interface{}{
+			"reason":          reason,
+			"challenge_token": challengeToken,
+		},
+	}
+}
@@ -0,0 +1,161 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"go-restaurant-management/config"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull = errors.New("mail queue is full")
+	ErrClosed    = errors.New("mailer is closed")
+)
+
+// Mailer sends messages in the background through a transport, retrying
+// failed deliveries with exponential backoff, so handlers never wait on SMTP.
+type Mailer struct {
+	transport  Transport
+	from       string
+	maxRetries int
+	baseDelay  time.Duration
+
+	queue  chan Message
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+type Options struct {
+	From       string
+	QueueSize  int
+	Workers    int
+	MaxRetries int
+	BaseDelay  time.Duration // First retry delay, doubled on each attempt
+}
+
+func New(transport Transport, options Options) *Mailer {
+	if options.QueueSize <= 0 {
+		options.QueueSize = 100
+	}
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.BaseDelay <= 0 {
+		options.BaseDelay = time.Second
+	}
+
+	m := &Mailer{
+		transport:  transport,
+		from:       options.From,
+		maxRetries: options.MaxRetries,
+		baseDelay:  options.BaseDelay,
+		queue:      make(chan Message, options.QueueSize),
+	}
+
+	for i := 0; i < options.Workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+// NewMailer builds the mailer configured by the MAIL_* and SMTP_* settings.
+func NewMailer() (*Mailer, error) {
+	var transport Transport
+	switch config.Envs.MAIL_TRANSPORT {
+	case "smtp":
+		transport = NewSMTPTransport(SMTPConfig{
+			Host:        config.Envs.SMTP_HOST,
+			Port:        config.Envs.SMTP_PORT,
+			Username:    config.Envs.SMTP_USERNAME,
+			Password:    config.Envs.SMTP_PASSWORD,
+			ImplicitTLS: config.Envs.SMTP_IMPLICIT_TLS,
+		})
+	case "file":
+		transport = NewMaildirTransport(config.Envs.MAIL_DIR)
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", config.Envs.MAIL_TRANSPORT)
+	}
+
+	return New(transport, Options{
+		From:       config.Envs.MAIL_FROM,
+		QueueSize:  int(config.Envs.MAIL_QUEUE_SIZE),
+		Workers:    int(config.Envs.MAIL_WORKERS),
+		MaxRetries: int(config.Envs.MAIL_MAX_RETRIES),
+	}), nil
+}
+
+// Send queues the message and returns immediately. It fails when the message
+// is invalid or the queue is full, never because of the transport.
+func (m *Mailer) Send(message Message) error {
+	if message.From == "" {
+		message.From = m.from
+	}
+	if _, err := message.Bytes(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return ErrClosed
+	}
+
+	select {
+	case m.queue <- message:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// SendTemplate renders the named template and queues the message.
+func (m *Mailer) SendTemplate(to string, subject string, name string, data any) error {
+	text, html, err := Render(name, data)
+	if err != nil {
+		return err
+	}
+	return m.Send(Message{To: []string{to}, Subject: subject, Text: text, HTML: html})
+}
+
+// Close stops accepting messages and waits for the queued ones to be sent.
+func (m *Mailer) Close() {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+func (m *Mailer) work() {
+	defer m.wg.Done()
+	for message := range m.queue {
+		m.deliver(message)
+	}
+}
+
+func (m *Mailer) deliver(message Message) {
+	delay := m.baseDelay
+	for attempt := 0; ; attempt++ {
+		err := m.transport.Send(message)
+		if err == nil {
+			return
+		}
+
+		if attempt >= m.maxRetries {
+			log.Printf("giving up sending mail %q to %v after %d attempts: %v", message.Subject, message.To, attempt+1, err)
+			return
+		}
+
+		log.Printf("error sending mail %q to %v, retrying in %v: %v", message.Subject, message.To, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,92 @@
+package mailer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type flakyTransport struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []Message
+}
+
+func (f *flakyTransport) Send(message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, message)
+	return nil
+}
+
+func TestMailer(t *testing.T) {
+	t.Run("should write messages to the maildir", func(t *testing.T) {
+		dir := t.TempDir()
+		text, html, err := Render("notification", NewNotificationData("Reset your password", "Open https://example.com/reset?token=abc\n\nThanks"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message := Message{From: "Restaurant <no-reply@example.com>", To: []string{"ana@example.com"}, Subject: "Reset your password", Text: text, HTML: html}
+		if err := NewMaildirTransport(dir).Send(message); err != nil {
+			t.Fatal(err)
+		}
+
+		files, _ := os.ReadDir(filepath.Join(dir, "new"))
+		if len(files) != 1 {
+			t.Fatalf("expected 1 message in new/, got %d", len(files))
+		}
+		content, _ := os.ReadFile(filepath.Join(dir, "new", files[0].Name()))
+		for _, expected := range []string{"To: <ana@example.com>", "multipart/alternative", "text/html", `<a href=3D"https://example.com/reset?token=3Dabc">`} {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("expected message to contain %q, got:\n%s", expected, content)
+			}
+		}
+	})
+
+	t.Run("should write the body of text only messages", func(t *testing.T) {
+		content, err := Message{From: "no-reply@example.com", To: []string{"ana@example.com"}, Subject: "Hi", Text: "Your table is ready"}.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(content), "text/plain") || !strings.HasSuffix(string(content), "\r\n\r\nYour table is ready") {
+			t.Errorf("expected a text/plain message with its body, got:\n%s", content)
+		}
+	})
+
+	t.Run("should retry failed deliveries", func(t *testing.T) {
+		transport := &flakyTransport{failures: 2}
+		m := New(transport, Options{From: "no-reply@example.com", MaxRetries: 3, BaseDelay: time.Millisecond})
+
+		if err := m.Send(Message{To: []string{"ana@example.com"}, Subject: "Hi", Text: "Hello"}); err != nil {
+			t.Fatal(err)
+		}
+		m.Close()
+
+		if transport.attempts != 3 || len(transport.sent) != 1 {
+			t.Errorf("expected 3 attempts and 1 message sent, got %d and %d", transport.attempts, len(transport.sent))
+		}
+		if err := m.Send(Message{To: []string{"ana@example.com"}, Subject: "Hi", Text: "Hello"}); !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed after Close, got %v", err)
+		}
+	})
+
+	t.Run("should reject invalid recipients", func(t *testing.T) {
+		m := New(&flakyTransport{}, Options{From: "no-reply@example.com"})
+		defer m.Close()
+
+		if err := m.Send(Message{To: []string{"not an address"}, Subject: "Hi", Text: "Hello"}); err == nil {
+			t.Error("expected invalid recipient to be rejected")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+var htmlFuncs = htmltemplate.FuncMap{
+	// linkify escapes the text and turns URLs into anchors
+	"linkify": func(text string) htmltemplate.HTML {
+		var out strings.Builder
+		last := 0
+		for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+			out.WriteString(htmltemplate.HTMLEscapeString(text[last:loc[0]]))
+			url := htmltemplate.HTMLEscapeString(text[loc[0]:loc[1]])
+			fmt.Fprintf(&out, `<a href="%s">%s</a>`, url, url)
+			last = loc[1]
+		}
+		out.WriteString(htmltemplate.HTMLEscapeString(text[last:]))
+		return htmltemplate.HTML(out.String())
+	},
+}
+
+// Render executes templates/<name>.txt and templates/<name>.html, the latter
+// wrapped in the common layout. Either may be missing, but not both.
+func Render(name string, data any) (text string, html string, err error) {
+	if content, readErr := templateFS.ReadFile("templates/" + name + ".txt"); readErr == nil {
+		tmpl, err := texttemplate.New(name).Parse(string(content))
+		if err != nil {
+			return "", "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", "", err
+		}
+		text = buf.String()
+	}
+
+	if _, readErr := templateFS.ReadFile("templates/" + name + ".html"); readErr == nil {
+		tmpl, err := htmltemplate.New(name).Funcs(htmlFuncs).ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return "", "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+			return "", "", err
+		}
+		html = buf.String()
+	}
+
+	if text == "" && html == "" {
+		return "", "", fmt.Errorf("mail template %q not found", name)
+	}
+	return text, html, nil
+}
+
+// NotificationData is the data of the generic "notification" template.
+type NotificationData struct {
+	Subject    string
+	Paragraphs []string
+}
+
+// NewNotificationData splits a plain text body into paragraphs.
+func NewNotificationData(subject string, body string) NotificationData {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return NotificationData{Subject: subject, Paragraphs: paragraphs}
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Transport delivers a rendered message.
+type Transport interface {
+	Send(message Message) error
+}
+
+type SMTPConfig struct {
+	Host        string
+	Port        string
+	Username    string
+	Password    string
+	ImplicitTLS bool
+}
+
+type smtpTransport struct {
+	config SMTPConfig
+}
+
+func (s *smtpTransport) Send(message Message) error {
+	raw, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+	var recipients []string
+	for _, to := range message.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, address.Address)
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+	if !s.config.ImplicitTLS {
+		// SendMail upgrades with STARTTLS when the server offers it
+		return smtp.SendMail(addr, auth, from.Address, recipients, raw)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: s.config.Host})
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func NewSMTPTransport(config SMTPConfig) Transport {
+	return &smtpTransport{config: config}
+}
+
+// maildirTransport stores each message as a file in a maildir, so local
+// development and tests can inspect what would have been sent. Messages are
+// written to tmp/ and renamed into new/, as the maildir format requires.
+type maildirTransport struct {
+	dir     string
+	counter atomic.Uint64
+}
+
+func (m *maildirTransport) Send(message Message) error {
+	raw, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().UnixNano(), os.Getpid(), m.counter.Add(1), hostname)
+
+	tmpPath := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(m.dir, "new", name))
+}
+
+func NewMaildirTransport(dir string) Transport {
+	return &maildirTransport{dir: dir}
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-restaurant-management/internal/domain/idempotency"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/utils"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the first response to unsafe requests sent with an
+// Idempotency-Key header, so retries don't run twice. Keys are scoped by
+// authenticated user or API key; anonymous requests are passed through.
+//
+// Responses are stored as they were sent, so it only applies to the routes
+// that opted in, and never stores responses marked Cache-Control: no-store,
+// like the ones carrying credentials.
+type Idempotency struct {
+	store         idempotency.IdempotencyRepository
+	authenticator *auth.Authenticator
+	// routes are the path prefixes of the routes that opted in
+	routes []string
+	// ttl is how long responses are replayed, lockTimeout how long a request
+	// that never finished keeps its key
+	ttl          time.Duration
+	lockTimeout  time.Duration
+	maxBodyBytes int64
+
+	// ResolveAPIKey is used like in RateLimiter. When nil, requests with an
+	// API key are passed through.
+	ResolveAPIKey func(key string) (string, bool)
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewIdempotency(store idempotency.IdempotencyRepository, authenticator *auth.Authenticator, routes []string, ttl time.Duration, lockTimeout time.Duration, maxBodyBytes int64) *Idempotency {
+	return &Idempotency{
+		store:         store,
+		authenticator: authenticator,
+		routes:        routes,
+		ttl:           ttl,
+		lockTimeout:   lockTimeout,
+		maxBodyBytes:  maxBodyBytes,
+		now:           time.Now,
+	}
+}
+
+func (i *Idempotency) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || !isUnsafeMethod(r.Method) || !i.appliesTo(r.URL.Path) {
+			next(w, r)
+			return
+		}
+
+		if !isValidIdempotencyKey(key) {
+			utils.WriteError(w, exceptions.NewValidationError("Idempotency-Key", "The Idempotency-Key header must have up to 255 visible ASCII characters"))
+			return
+		}
+
+		// Keys of revoked sessions must not replay what they were sent
+		scope, ok := authenticatedClientKey(r, i.authenticator.ValidateAccessToken, i.ResolveAPIKey)
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, i.maxBodyBytes+1))
+		if err != nil {
+			utils.WriteError(w, exceptions.NewValidationError("body", "The request body could not be read"))
+			return
+		}
+		if int64(len(body)) > i.maxBodyBytes {
+			utils.WriteError(w, exceptions.NewPayloadTooLargeError(i.maxBodyBytes))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := i.now()
+		i.sweep(now)
+
+		fingerprint := requestFingerprint(r, body)
+		record, reserved, err := i.store.Reserve(scope, key, fingerprint, now, now.Add(i.lockTimeout))
+		if err != nil {
+			utils.WriteError(w, err)
+			return
+		}
+		if !reserved {
+			replay(w, record, fingerprint, now)
+			return
+		}
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := i.store.Release(scope, key); err != nil {
+				log.Printf("error releasing idempotency key of %s: %v", scope, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w, before: w.Header().Clone()}
+		next(recorder, r)
+		if recorder.status == 0 {
+			recorder.WriteHeader(http.StatusOK)
+		}
+
+		// Failed requests aren't expected to change anything, the key is
+		// released so the client can retry once it fixed the request
+		if recorder.status >= http.StatusBadRequest {
+			return
+		}
+		if isNoStore(recorder.header) {
+			return
+		}
+
+		record = idempotency.Record{
+			Fingerprint: fingerprint,
+			Status:      recorder.status,
+			Header:      recorder.header,
+			Body:        recorder.body.Bytes(),
+			ExpiresAt:   i.now().Add(i.ttl),
+		}
+		if err := i.store.Complete(scope, key, record); err != nil {
+			log.Printf("error saving the response of an idempotency key of %s: %v", scope, err)
+			return
+		}
+		completed = true
+	}
+}
+
+func replay(w http.ResponseWriter, record idempotency.Record, fingerprint string, now time.Time) {
+	if record.Fingerprint != fingerprint {
+		utils.WriteError(w, exceptions.NewIdempotencyKeyReusedError())
+		return
+	}
+	if record.Status == 0 {
+		utils.WriteError(w, exceptions.NewIdempotencyKeyInUseError(record.ExpiresAt.Sub(now)))
+		return
+	}
+
+	for key, values := range record.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// sweep deletes expired records at most once an hour, in the background.
+func (i *Idempotency) sweep(now time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if now.Sub(i.lastSweep) < time.Hour {
+		return
+	}
+	i.lastSweep = now
+
+	go func() {
+		if err := i.store.DeleteExpired(now); err != nil {
+			log.Printf("error deleting expired idempotency keys: %v", err)
+		}
+	}()
+}
+
+func (i *Idempotency) appliesTo(path string) bool {
+	for _, route := range i.routes {
+		if path == route || strings.HasPrefix(path, strings.TrimSuffix(route, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIdempotencyRoutes reads path prefixes separated by ";".
+func ParseIdempotencyRoutes(spec string) []string {
+	var routes []string
+	for _, route := range strings.Split(spec, ";") {
+		if route = strings.TrimSpace(route); route != "" {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func isNoStore(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnsafeMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response to the client and keeps the status,
+// the headers set by the handler and the body.
+type responseRecorder struct {
+	http.ResponseWriter
+	before http.Header
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+
+	// Headers set before, like the rate limit ones, belong to this request
+	r.header = http.Header{}
+	for key, values := range r.ResponseWriter.Header() {
+		if !slices.Equal(values, r.before[key]) {
+			r.header[key] = slices.Clone(values)
+		}
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
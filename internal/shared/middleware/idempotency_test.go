@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"go-restaurant-management/internal/domain/idempotency"
+	"go-restaurant-management/internal/shared/auth"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+}
+
+func (m *memoryIdempotencyStore) Reserve(scope string, key string, fingerprint string, now time.Time, expiresAt time.Time) (idempotency.Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.records[scope+"|"+key]; ok && !record.ExpiresAt.Before(now) {
+		return record, false, nil
+	}
+	m.records[scope+"|"+key] = idempotency.Record{Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return idempotency.Record{}, true, nil
+}
+
+func (m *memoryIdempotencyStore) Complete(scope string, key string, record idempotency.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[scope+"|"+key] = record
+	return nil
+}
+
+func (m *memoryIdempotencyStore) Release(scope string, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records[scope+"|"+key].Status == 0 {
+		delete(m.records, scope+"|"+key)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyStore) DeleteExpired(now time.Time) error {
+	return nil
+}
+
+// revokedSessions checks sessions against a set of revoked ones.
+type revokedSessions map[string]bool
+
+func (r revokedSessions) IsSessionActive(sessionID string) bool { return !r[sessionID] }
+
+func TestIdempotency(t *testing.T) {
+	token, err := auth.CreateJWT(auth.Claims{UserID: 3, Role: "waiter", SessionID: "tablet"}, auth.AccessToken, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := revokedSessions{}
+	newIdempotency := func() *Idempotency {
+		authenticator := auth.NewAuthenticator(revoked, nil)
+		return NewIdempotency(&memoryIdempotencyStore{records: map[string]idempotency.Record{}}, authenticator, []string{"/api/orders"}, time.Hour, time.Minute, 1024)
+	}
+
+	requestPath := func(h http.HandlerFunc, path string, body string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+	request := func(h http.HandlerFunc, body string, key string) *httptest.ResponseRecorder {
+		return requestPath(h, "/api/orders", body, key)
+	}
+
+	newHandler := func(status int) (http.HandlerFunc, *int) {
+		calls := 0
+		return newIdempotency().Handle(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Location", "/api/orders/1")
+			w.WriteHeader(status)
+			w.Write(body)
+		}), &calls
+	}
+
+	t.Run("should replay the first response on retries", func(t *testing.T) {
+		h, calls := newHandler(http.StatusCreated)
+
+		first := request(h, `{"table": 4}`, "order-1")
+		retry := request(h, `{"table": 4}`, "order-1")
+
+		if *calls != 1 {
+			t.Fatalf("expected the handler to run once, ran %d times", *calls)
+		}
+		if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the first response to be replayed, got %v: %s", retry.Code, retry.Body.String())
+		}
+		if retry.Header().Get("Location") != "/api/orders/1" || retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("unexpected replayed headers: %v", retry.Header())
+		}
+	})
+
+	t.Run("should return 409 when the key is reused with another body", func(t *testing.T) {
+		h, calls := newHandler(http.StatusCreated)
+
+		request(h, `{"table": 4}`, "order-1")
+		rr := request(h, `{"table": 5}`, "order-1")
+
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected 409, got %v: %s", rr.Code, rr.Body.String())
+		}
+		if *calls != 1 {
+			t.Errorf("expected the handler to run once, ran %d times", *calls)
+		}
+	})
+
+	t.Run("should run failed requests again", func(t *testing.T) {
+		h, calls := newHandler(http.StatusBadRequest)
+
+		request(h, `{"table": 4}`, "order-1")
+		request(h, `{"table": 4}`, "order-1")
+
+		if *calls != 2 {
+			t.Errorf("expected the handler to run twice, ran %d times", *calls)
+		}
+	})
+
+	t.Run("should reject invalid keys", func(t *testing.T) {
+		h, calls := newHandler(http.StatusCreated)
+
+		if rr := request(h, `{"table": 4}`, "order 1"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %v", rr.Code)
+		}
+		if *calls != 0 {
+			t.Errorf("expected the handler not to run, ran %d times", *calls)
+		}
+	})
+
+	t.Run("should only apply to the routes that opted in", func(t *testing.T) {
+		h, calls := newHandler(http.StatusCreated)
+
+		requestPath(h, "/api/admin/api-keys", `{"name": "kiosk"}`, "key-1")
+		requestPath(h, "/api/admin/api-keys", `{"name": "kiosk"}`, "key-1")
+
+		if *calls != 2 {
+			t.Errorf("expected the handler to run twice, ran %d times", *calls)
+		}
+	})
+
+	t.Run("should not store responses marked no-store", func(t *testing.T) {
+		calls := 0
+		h := newIdempotency().Handle(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		request(h, `{"table": 4}`, "order-1")
+		if rr := request(h, `{"table": 4}`, "order-1"); rr.Header().Get("Idempotent-Replayed") != "" {
+			t.Error("expected the response not to be replayed")
+		}
+		if calls != 2 {
+			t.Errorf("expected the handler to run twice, ran %d times", calls)
+		}
+	})
+
+	t.Run("should not replay to revoked sessions", func(t *testing.T) {
+		h, calls := newHandler(http.StatusCreated)
+
+		request(h, `{"table": 4}`, "order-1")
+		revoked["tablet"] = true
+		t.Cleanup(func() { delete(revoked, "tablet") })
+
+		if rr := request(h, `{"table": 4}`, "order-1"); rr.Header().Get("Idempotent-Replayed") != "" {
+			t.Error("expected the response not to be replayed")
+		}
+		if *calls != 2 {
+			t.Errorf("expected the request to be passed to the handler, ran %d times", *calls)
+		}
+	})
+}
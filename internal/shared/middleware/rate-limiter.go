@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-restaurant-management/internal/shared/auth"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"go-restaurant-management/internal/shared/utils"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitRule allows Limit requests per Period to paths starting with Prefix.
+type RateLimitRule struct {
+	Prefix string
+	Limit  int
+	Period time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter is a token bucket limiter keyed by authenticated user, API key
+// or client IP, with one bucket per client and rule.
+type RateLimiter struct {
+	defaultRule RateLimitRule
+	rules       []RateLimitRule
+
+	// ResolveAPIKey maps an X-API-Key header to a stable identifier. Keys it
+	// rejects fall back to the client IP, so made-up keys can't be used to get
+	// fresh buckets. When nil, API keys are ignored.
+	ResolveAPIKey func(key string) (string, bool)
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	apiKeys   map[string]resolvedAPIKey
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// resolvedAPIKey remembers what ResolveAPIKey returned for a key, so the
+// clients using it aren't looked up on every request.
+type resolvedAPIKey struct {
+	id      string
+	expires time.Time
+}
+
+const apiKeyCacheTTL = time.Minute
+
+func NewRateLimiter(defaultRule RateLimitRule, rules ...RateLimitRule) *RateLimiter {
+	// Longest prefix first, so the most specific rule wins
+	sort.Slice(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+
+	return &RateLimiter{
+		defaultRule: defaultRule,
+		rules:       rules,
+		buckets:     make(map[string]*bucket),
+		apiKeys:     make(map[string]resolvedAPIKey),
+		now:         time.Now,
+	}
+}
+
+func (l *RateLimiter) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule := l.ruleFor(r.URL.Path)
+		ipKey := "ip:" + utils.GetClientIP(r)
+
+		client, ok := authenticatedClientKey(r, parseAccessToken, l.cachedAPIKey)
+		if key := r.Header.Get("X-API-Key"); !ok && key != "" && l.ResolveAPIKey != nil {
+			// Looking a key up hits the database, so keys that weren't seen
+			// recently are limited by address first
+			if !l.allow(w, rule, ipKey) {
+				return
+			}
+			if client, ok = l.resolveAPIKey(key); !ok {
+				next(w, r)
+				return
+			}
+		}
+		if !ok {
+			client = ipKey
+		}
+
+		if !l.allow(w, rule, client) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allow takes a token from the bucket of client, setting the rate limit
+// headers, and answers 429 when it is empty.
+func (l *RateLimiter) allow(w http.ResponseWriter, rule RateLimitRule, client string) bool {
+	allowed, remaining, reset := l.take(rule.Prefix+"|"+client, rule)
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(rule.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+
+	if !allowed {
+		utils.WriteError(w, exceptions.NewTooManyRequestsError("rate limit exceeded", reset))
+	}
+	return allowed
+}
+
+// cachedAPIKey only knows the keys resolveAPIKey accepted in the last minute.
+func (l *RateLimiter) cachedAPIKey(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	resolved, ok := l.apiKeys[hashAPIKey(key)]
+	if !ok || l.now().After(resolved.expires) {
+		return "", false
+	}
+	return resolved.id, true
+}
+
+func (l *RateLimiter) resolveAPIKey(key string) (string, bool) {
+	id, ok := l.ResolveAPIKey(key)
+	if !ok {
+		return "", false
+	}
+
+	l.mu.Lock()
+	l.apiKeys[hashAPIKey(key)] = resolvedAPIKey{id: id, expires: l.now().Add(apiKeyCacheTTL)}
+	l.mu.Unlock()
+	return apiKeyClientKey(id), true
+}
+
+func (l *RateLimiter) ruleFor(path string) RateLimitRule {
+	for _, rule := range l.rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return rule
+		}
+	}
+	return l.defaultRule
+}
+
+// authenticatedClientKey identifies the user of an access token validateToken
+// accepts or the API key resolveAPIKey accepts. Roles are left to the handlers.
+func authenticatedClientKey(r *http.Request, validateToken func(token string) (*auth.Claims, error), resolveAPIKey func(key string) (string, bool)) (string, bool) {
+	if token := auth.GetBearerToken(r); token != "" {
+		if claims, err := validateToken(token); err == nil {
+			return "user:" + strconv.Itoa(claims.UserID), true
+		}
+	}
+
+	if key := r.Header.Get("X-API-Key"); key != "" && resolveAPIKey != nil {
+		if id, ok := resolveAPIKey(key); ok {
+			return apiKeyClientKey(id), true
+		}
+	}
+
+	return "", false
+}
+
+func apiKeyClientKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// hashAPIKey keeps the keys themselves out of memory.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseAccessToken keys rate limits by user without a session lookup on
+// every request, a revoked session is refused by the handler anyway.
+func parseAccessToken(token string) (*auth.Claims, error) {
+	return auth.ParseJWT(token, auth.AccessToken)
+}
+
+// take consumes a token from the bucket. When allowed, reset is the time until
+// the bucket is full again, otherwise it is the time until the next token.
+func (l *RateLimiter) take(key string, rule RateLimitRule) (allowed bool, remaining int, reset time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(rule.Limit)
+	perToken := rule.Period / time.Duration(rule.Limit)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated)
+	b.tokens = math.Min(capacity, b.tokens+elapsed.Seconds()/perToken.Seconds())
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	b.tokens--
+	return true, int(b.tokens), time.Duration((capacity - b.tokens) * float64(perToken))
+}
+
+// sweep drops buckets that have been idle long enough to be full again,
+// since a new bucket would be identical.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	longest := l.defaultRule.Period
+	for _, rule := range l.rules {
+		longest = max(longest, rule.Period)
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > longest {
+			delete(l.buckets, key)
+		}
+	}
+	for key, resolved := range l.apiKeys {
+		if now.After(resolved.expires) {
+			delete(l.apiKeys, key)
+		}
+	}
+}
+
+// ParseRateLimit parses "<requests>/<period>", e.g. "120/1m".
+func ParseRateLimit(spec string) (int, time.Duration, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(spec), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("rate limit %q must be in the form <requests>/<period>", spec)
+	}
+
+	limit, err := strconv.Atoi(requests)
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("rate limit %q has an invalid number of requests", spec)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return 0, 0, fmt.Errorf("rate limit %q has an invalid period", spec)
+	}
+
+	return limit, duration, nil
+}
+
+// ParseRateLimitRules parses the default limit and the per route limits in
+// the form "<path prefix>=<requests>/<period>;...".
+func ParseRateLimitRules(defaultSpec string, routesSpec string) (RateLimitRule, []RateLimitRule, error) {
+	limit, period, err := ParseRateLimit(defaultSpec)
+	if err != nil {
+		return RateLimitRule{}, nil, err
+	}
+	defaultRule := RateLimitRule{Prefix: "", Limit: limit, Period: period}
+
+	var rules []RateLimitRule
+	for _, route := range strings.Split(routesSpec, ";") {
+		if strings.TrimSpace(route) == "" {
+			continue
+		}
+
+		prefix, spec, ok := strings.Cut(route, "=")
+		if !ok {
+			return RateLimitRule{}, nil, fmt.Errorf("route rate limit %q must be in the form <path prefix>=<requests>/<period>", route)
+		}
+
+		limit, period, err := ParseRateLimit(spec)
+		if err != nil {
+			return RateLimitRule{}, nil, err
+		}
+		rules = append(rules, RateLimitRule{Prefix: strings.TrimSpace(prefix), Limit: limit, Period: period})
+	}
+
+	return defaultRule, rules, nil
+}
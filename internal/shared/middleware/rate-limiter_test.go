@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	request := func(h http.HandlerFunc, path string, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+
+	t.Run("should return 429 once the bucket is empty and refill over time", func(t *testing.T) {
+		now := time.Now()
+		limiter := NewRateLimiter(RateLimitRule{Limit: 2, Period: time.Minute})
+		limiter.now = func() time.Time { return now }
+		h := limiter.Limit(ok)
+
+		for i := 0; i < 2; i++ {
+			if rr := request(h, "/api/menus", "198.51.100.1:1000"); rr.Code != http.StatusOK {
+				t.Fatalf("request %d: expected 200, got %v", i+1, rr.Code)
+			}
+		}
+
+		rr := request(h, "/api/menus", "198.51.100.1:1000")
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected 429, got %v", rr.Code)
+		}
+		if rr.Header().Get("Retry-After") != "30" {
+			t.Errorf("expected Retry-After of 30 seconds, got %q", rr.Header().Get("Retry-After"))
+		}
+		if rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != "0" {
+			t.Errorf("unexpected rate limit headers: %v", rr.Header())
+		}
+
+		// Other clients have their own bucket
+		if rr := request(h, "/api/menus", "198.51.100.2:1000"); rr.Code != http.StatusOK {
+			t.Errorf("expected other clients to be allowed, got %v", rr.Code)
+		}
+
+		now = now.Add(30 * time.Second)
+		if rr := request(h, "/api/menus", "198.51.100.1:1000"); rr.Code != http.StatusOK {
+			t.Errorf("expected a token to be refilled, got %v", rr.Code)
+		}
+	})
+
+	t.Run("should apply the most specific route rule", func(t *testing.T) {
+		defaultRule, rules, err := ParseRateLimitRules("100/1m", "/api/=50/1m; /api/menus=1/1h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := NewRateLimiter(defaultRule, rules...).Limit(ok)
+
+		request(h, "/api/menus/1", "198.51.100.1:1000")
+		if rr := request(h, "/api/menus/2", "198.51.100.1:1000"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected the menus rule to apply, got %v", rr.Code)
+		}
+		if rr := request(h, "/api/auth/login", "198.51.100.1:1000"); rr.Header().Get("RateLimit-Limit") != "50" {
+			t.Errorf("expected the /api/ rule to apply, got limit %q", rr.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	withKey := func(h http.HandlerFunc, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/menus", nil)
+		req.RemoteAddr = "198.51.100.1:1000"
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+
+	t.Run("should limit unknown API keys by address before looking them up", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimitRule{Limit: 2, Period: time.Minute})
+		lookups := 0
+		limiter.ResolveAPIKey = func(key string) (string, bool) {
+			lookups++
+			return "", false
+		}
+		h := limiter.Limit(ok)
+
+		for i, key := range []string{"rk_made_up_1", "rk_made_up_2", "rk_made_up_3", "rk_made_up_4"} {
+			rr := withKey(h, key)
+			if want := i < 2; (rr.Code == http.StatusOK) != want {
+				t.Errorf("request %d: unexpected status %v", i+1, rr.Code)
+			}
+		}
+		if lookups != 2 {
+			t.Errorf("expected 2 lookups, got %d", lookups)
+		}
+	})
+
+	t.Run("should look known API keys up once a minute", func(t *testing.T) {
+		now := time.Now()
+		limiter := NewRateLimiter(RateLimitRule{Limit: 10, Period: time.Minute})
+		limiter.now = func() time.Time { return now }
+		lookups := 0
+		limiter.ResolveAPIKey = func(key string) (string, bool) {
+			lookups++
+			return "rk_live", true
+		}
+		h := limiter.Limit(ok)
+
+		for i := 0; i < 3; i++ {
+			withKey(h, "rk_live_secret")
+		}
+		if lookups != 1 {
+			t.Errorf("expected 1 lookup, got %d", lookups)
+		}
+
+		now = now.Add(2 * time.Minute)
+		withKey(h, "rk_live_secret")
+		if lookups != 2 {
+			t.Errorf("expected the key to be looked up again, got %d lookups", lookups)
+		}
+	})
+
+	t.Run("should reject invalid rules", func(t *testing.T) {
+		for _, spec := range []string{"", "abc", "10", "0/1m", "10/forever"} {
+			if _, _, err := ParseRateLimit(spec); err == nil {
+				t.Errorf("expected %q to be rejected", spec)
+			}
+		}
+		if _, _, err := ParseRateLimitRules("10/1m", "/api/menus"); err == nil {
+			t.Error("expected route without limit to be rejected")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"fmt"
+	"go-restaurant-management/config"
+	"go-restaurant-management/internal/shared/mailer"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users, e.g. password reset links.
+type Notifier interface {
+	Send(message Message) error
+	// Close waits for the messages still being delivered
+	Close() error
+}
+
+// consoleNotifier writes messages to the log, for development.
+type consoleNotifier struct{}
+
+func (c *consoleNotifier) Send(message Message) error {
+	log.Printf("notification to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+func (c *consoleNotifier) Close() error {
+	return nil
+}
+
+func NewConsoleNotifier() Notifier {
+	return &consoleNotifier{}
+}
+
+// fileNotifier writes each message to its own file in dir, for development
+// and tests that need to read what was sent.
+type fileNotifier struct {
+	dir string
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (f *fileNotifier) Send(message Message) error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.txt", time.Now().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(message.To, "_"))
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, message.Body)
+
+	return os.WriteFile(filepath.Join(f.dir, name), []byte(content), 0o600)
+}
+
+func (f *fileNotifier) Close() error {
+	return nil
+}
+
+func NewFileNotifier(dir string) Notifier {
+	return &fileNotifier{dir: dir}
+}
+
+// emailNotifier renders messages with the mailer's notification template and
+// queues them for delivery.
+type emailNotifier struct {
+	mailer *mailer.Mailer
+}
+
+func (e *emailNotifier) Send(message Message) error {
+	return e.mailer.SendTemplate(message.To, message.Subject, "notification", mailer.NewNotificationData(message.Subject, message.Body))
+}
+
+func (e *emailNotifier) Close() error {
+	e.mailer.Close()
+	return nil
+}
+
+func NewEmailNotifier(m *mailer.Mailer) Notifier {
+	return &emailNotifier{mailer: m}
+}
+
+// NewNotifier builds the notifier selected by NOTIFIER_TRANSPORT.
+func NewNotifier() (Notifier, error) {
+	switch config.Envs.NOTIFIER_TRANSPORT {
+	case "console":
+		return NewConsoleNotifier(), nil
+	case "file":
+		return NewFileNotifier(config.Envs.NOTIFIER_DIR), nil
+	case "email":
+		m, err := mailer.NewMailer()
+		if err != nil {
+			return nil, err
+		}
+		return NewEmailNotifier(m), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier transport %q", config.Envs.NOTIFIER_TRANSPORT)
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"go-restaurant-management/config"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeps uploaded files, e.g. avatars. Keys are slash separated paths
+// like "avatars/42.jpg".
+type Storage interface {
+	// Put stores the content under key and returns its public URL.
+	Put(key string, content io.Reader) (string, error)
+	Delete(key string) error
+	// KeyFromURL returns the key of a URL returned by Put, or false if the
+	// URL doesn't belong to this storage.
+	KeyFromURL(url string) (string, bool)
+}
+
+// LocalStorage writes files under dir and serves them at baseURL.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func (l *LocalStorage) Put(key string, content io.Reader) (string, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	// Written to a temporary file first so readers never see partial files
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(l.baseURL, "/") + "/" + key, nil
+}
+
+func (l *LocalStorage) Delete(key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) KeyFromURL(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, strings.TrimSuffix(l.baseURL, "/")+"/")
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+// path rejects keys that would escape dir.
+func (l *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+// Handler serves the stored files, without directory listings.
+func (l *LocalStorage) Handler() http.Handler {
+	files := http.StripPrefix(strings.TrimSuffix(l.baseURL, "/"), http.FileServer(http.Dir(l.dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
+
+func NewLocalStorage(dir string, baseURL string) *LocalStorage {
+	return &LocalStorage{dir: dir, baseURL: baseURL}
+}
+
+// NewStorage builds the storage selected by STORAGE_DRIVER. The handler serves
+// the files when the application has to, and is nil otherwise.
+func NewStorage() (Storage, http.Handler, error) {
+	switch config.Envs.STORAGE_DRIVER {
+	case "local":
+		local := NewLocalStorage(config.Envs.STORAGE_DIR, config.Envs.STORAGE_BASE_URL)
+		return local, local.Handler(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", config.Envs.STORAGE_DRIVER)
+	}
+}
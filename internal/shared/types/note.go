@@ -3,10 +3,10 @@ package types
 import "time"
 
 type Note struct {
-	ID        uint64    `json:"id"`
+	ID        uint64    `json:"id" patch:"readonly"`
 	Title     string    `json:"title"`
 	Content   string    `json:"content"`
 	Order_id  uint64    `json:"order_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time `json:"created_at" patch:"readonly"`
+	UpdatedAt time.Time `json:"updated_at" patch:"readonly"`
 }
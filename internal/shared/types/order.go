@@ -3,9 +3,13 @@ package types
 import "time"
 
 type Order struct {
-	ID         uint64    `json:"id"`
+	ID         uint64    `json:"id" patch:"readonly"`
+	Reference  string    `json:"reference" validate:"required,max=50"`
 	Order_Date time.Time `json:"order_date" validate:"required"`
 	Table_id   uint64    `json:"table_id" validate:"required"`
-	Created_at time.Time `json:"created_at"`
-	Updated_at time.Time `json:"updated_at"`
+	Waiter_id  uint64    `json:"waiter_id" validate:"required"`
+	Created_at time.Time `json:"created_at" patch:"readonly"`
+	Updated_at time.Time `json:"updated_at" patch:"readonly"`
+	// Version grows on every change, it is the ETag of the order
+	Version int `json:"-"`
 }
@@ -3,7 +3,87 @@ package types
 type RegisterUserRequest struct {
 	First_name string `json:"first_name" validate:"required,min=2,max=100"`
 	Last_name  string `json:"last_name" validate:"required,min=2,max=100"`
-	Password   string `json:"password" validate:"required,min=6,max=100"`
+	Password   string `json:"password" validate:"required,password_policy,password_common,password_personal=Email First_name Last_name"`
 	Email      string `json:"email" validate:"required,email|unique"`
-	Phone      string `json:"phone" validate:"required|unique|numeric|len=11"`
+	Phone      string `json:"phone" validate:"required,br_mobile"`
+}
+
+type LoginUserRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	Refresh_token string `json:"refresh_token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password_policy,password_common"`
+}
+
+type TwoFactorVerifyRequest struct {
+	Challenge_token string `json:"challenge_token" validate:"required"`
+	Code            string `json:"code" validate:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type SetPinRequest struct {
+	Password string `json:"password" validate:"required"`
+	Pin      string `json:"pin" validate:"required,number,min=4,max=6"`
+}
+
+type PinLoginRequest struct {
+	User_id int    `json:"user_id" validate:"required"`
+	Pin     string `json:"pin" validate:"required,number,min=4,max=6"`
+}
+
+type RegisterTerminalRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type CreateApiKeyRequest struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Scopes      []string `json:"scopes" validate:"required,min=1"`
+	Allowed_ips []string `json:"allowed_ips"`
+}
+
+// ProfilePatch is the part of the profile users edit themselves. PATCH
+// requests are merge patches applied onto it.
+type ProfilePatch struct {
+	First_name string `json:"first_name" validate:"required,min=2,max=100"`
+	Last_name  string `json:"last_name" validate:"required,min=2,max=100"`
+	Phone      string `json:"phone" validate:"required,br_mobile"`
+}
+
+// UserPatch is the part of a user managers edit, merge patches are applied
+// onto it.
+type UserPatch struct {
+	First_name string `json:"first_name" validate:"required,min=2,max=100"`
+	Last_name  string `json:"last_name" validate:"required,min=2,max=100"`
+	Phone      string `json:"phone" validate:"required,br_mobile"`
+	Role       string `json:"role" validate:"required,oneof=customer waiter cashier manager admin"`
+}
+
+// EraseDataRequest confirms an erasure, code is only needed with 2FA enabled.
+type EraseDataRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code"`
+}
+
+type ChangePasswordRequest struct {
+	Current_password string `json:"current_password" validate:"required"`
+	Password         string `json:"password" validate:"required,password_policy,password_common"`
 }
@@ -3,7 +3,7 @@ package types
 import "time"
 
 type User struct {
-	ID            uint64    `json:"id"`
+	ID            uint64    `json:"id" patch:"readonly"`
 	First_name    string    `json:"first_name" validate:"required,min=2,max=100"`
 	Last_name     string    `json:"last_name" validate:"required,min=2,max=100"`
 	Password      string    `json:"password" validate:"required,min=6,max=100"`
@@ -12,6 +12,6 @@ type User struct {
 	Phone         string    `json:"phone" validate:"required"`
 	Token         string    `json:"token"`
 	Refresh_token string    `json:"refresh_token"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedAt     time.Time `json:"created_at" patch:"readonly"`
+	UpdatedAt     time.Time `json:"updated_at" patch:"readonly"`
 }
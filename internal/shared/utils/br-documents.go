@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Area codes (DDD) assigned by Anatel.
+var validDDDs = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true, "22": true, "24": true, "27": true, "28": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "37": true, "38": true,
+	"41": true, "42": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "53": true, "54": true, "55": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true, "66": true, "67": true, "68": true, "69": true,
+	"71": true, "73": true, "74": true, "75": true, "77": true, "79": true,
+	"81": true, "82": true, "83": true, "84": true, "85": true, "86": true, "87": true, "88": true, "89": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true, "98": true, "99": true,
+}
+
+// NormalizeBrazilianMobile accepts a Brazilian mobile number in any common
+// format ("(11) 98765-4321", "+55 11 98765-4321", "11987654321") and returns
+// it in E.164 ("+5511987654321").
+func NormalizeBrazilianMobile(phone string) (string, bool) {
+	if strings.Trim(phone, "0123456789 +-().") != "" {
+		return "", false
+	}
+	digits := onlyDigits(phone)
+
+	if len(digits) == 13 && strings.HasPrefix(digits, "55") {
+		digits = digits[2:]
+	}
+	if len(digits) != 11 {
+		return "", false
+	}
+	if !validDDDs[digits[:2]] || digits[2] != '9' {
+		return "", false
+	}
+
+	return "+55" + digits, true
+}
+
+func IsValidBrazilianMobile(phone string) bool {
+	_, ok := NormalizeBrazilianMobile(phone)
+	return ok
+}
+
+// IsValidCPF checks the two CPF check digits. Punctuation is ignored.
+func IsValidCPF(cpf string) bool {
+	digits := onlyDigits(cpf)
+	if len(digits) != 11 || allSameDigit(digits) {
+		return false
+	}
+
+	return checkDigit(digits[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[9] &&
+		checkDigit(digits[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[10]
+}
+
+// IsValidCNPJ checks the two CNPJ check digits. Punctuation is ignored.
+func IsValidCNPJ(cnpj string) bool {
+	digits := onlyDigits(cnpj)
+	if len(digits) != 14 || allSameDigit(digits) {
+		return false
+	}
+
+	return checkDigit(digits[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[12] &&
+		checkDigit(digits[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) == digits[13]
+}
+
+// checkDigit computes a modulo 11 check digit, shared by CPF and CNPJ.
+func checkDigit(digits string, weights []int) byte {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func allSameDigit(digits string) bool {
+	return strings.Count(digits, digits[:1]) == len(digits)
+}
@@ -0,0 +1,78 @@
+package utils
+
+import "testing"
+
+func TestNormalizeBrazilianMobile(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"11987654321", "+5511987654321", true},
+		{"(11) 98765-4321", "+5511987654321", true},
+		{"+55 21 99876-5432", "+5521998765432", true},
+		{"5585912345678", "+5585912345678", true},
+		{"1187654321", "", false},      // landline, 10 digits
+		{"11887654321", "", false},     // missing leading 9
+		{"20987654321", "", false},     // DDD 20 does not exist
+		{"1198765432a", "", false},     // letters
+		{"+1 415 555 0100", "", false}, // not brazilian
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeBrazilianMobile(tt.input)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("NormalizeBrazilianMobile(%q) = (%q, %v), want (%q, %v)", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIsValidCPF(t *testing.T) {
+	valid := []string{"529.982.247-25", "52998224725", "111.444.777-35"}
+	invalid := []string{"529.982.247-24", "111.111.111-11", "1234567890", "", "abc"}
+
+	for _, cpf := range valid {
+		if !IsValidCPF(cpf) {
+			t.Errorf("expected %q to be a valid CPF", cpf)
+		}
+	}
+	for _, cpf := range invalid {
+		if IsValidCPF(cpf) {
+			t.Errorf("expected %q to be an invalid CPF", cpf)
+		}
+	}
+}
+
+func TestIsValidCNPJ(t *testing.T) {
+	valid := []string{"11.222.333/0001-81", "11222333000181", "45.997.418/0001-53"}
+	invalid := []string{"11.222.333/0001-80", "00.000.000/0000-00", "1122233300018", ""}
+
+	for _, cnpj := range valid {
+		if !IsValidCNPJ(cnpj) {
+			t.Errorf("expected %q to be a valid CNPJ", cnpj)
+		}
+	}
+	for _, cnpj := range invalid {
+		if IsValidCNPJ(cnpj) {
+			t.Errorf("expected %q to be an invalid CNPJ", cnpj)
+		}
+	}
+}
+
+func TestBrazilianValidationTags(t *testing.T) {
+	type document struct {
+		Phone    string `json:"phone" validate:"required,br_mobile"`
+		TaxID    string `json:"tax_id" validate:"cpf|cnpj"`
+		Personal string `json:"personal" validate:"omitempty,cpf"`
+	}
+
+	if err := ValidateStruct(document{Phone: "11987654321", TaxID: "11.222.333/0001-81"}); err != nil {
+		t.Errorf("expected valid document, got %v", err)
+	}
+
+	err := ValidateStruct(document{Phone: "11987654321", TaxID: "123"})
+	if err == nil {
+		t.Fatal("expected validation error for tax_id")
+	}
+}
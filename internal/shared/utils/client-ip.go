@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"go-restaurant-management/config"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+var trustedProxies = sync.OnceValue(func() []netip.Prefix {
+	proxies, err := ParseTrustedProxies(config.Envs.TRUSTED_PROXIES)
+	if err != nil {
+		log.Printf("ignoring proxy headers, TRUSTED_PROXIES is invalid: %v", err)
+		return nil
+	}
+	return proxies
+})
+
+// ParseTrustedProxies reads a comma separated list of IPs and CIDRs.
+func ParseTrustedProxies(spec string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR", entry)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// GetClientIP returns the address of the client that made the request.
+// Proxy headers are only honored when the request comes from one of the
+// TRUSTED_PROXIES, since any client can set them.
+func GetClientIP(r *http.Request) string {
+	return clientIP(r, trustedProxies())
+}
+
+// clientIP walks X-Forwarded-For from the right, where the trusted proxies
+// appended the address they got the request from, and returns the first
+// address that isn't a trusted proxy. Entries further left were sent by the
+// client and can't be trusted.
+func clientIP(r *http.Request, proxies []netip.Prefix) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !isTrustedProxy(remote, proxies) {
+		return remote
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if len(hops) == 0 {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+		return remote
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if _, err := netip.ParseAddr(hops[i]); err != nil {
+			// Proxies append valid addresses, this was sent by the client
+			return remote
+		}
+		if !isTrustedProxy(hops[i], proxies) {
+			return hops[i]
+		}
+		remote = hops[i]
+	}
+	// Every hop is a proxy, the request was made from inside
+	return remote
+}
+
+func isTrustedProxy(ip string, proxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, proxy := range proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
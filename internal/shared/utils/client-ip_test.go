@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"should use the peer without proxy headers", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"should ignore headers of untrusted peers", "203.0.113.7:4000", "198.51.100.1", "203.0.113.7"},
+		{"should use the hop added by the proxy", "10.0.0.2:4000", "198.51.100.1", "198.51.100.1"},
+		{"should ignore hops sent by the client", "10.0.0.2:4000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"should skip every trusted proxy", "10.0.0.2:4000", "1.2.3.4, 198.51.100.1, 192.168.1.10, 10.0.0.3", "198.51.100.1"},
+		{"should stop at hops that aren't addresses", "10.0.0.2:4000", "198.51.100.1, garbage, 10.0.0.3", "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := clientIP(r, proxies); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("should refuse invalid entries", func(t *testing.T) {
+		if _, err := ParseTrustedProxies("10.0.0.0/8, proxy.local"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
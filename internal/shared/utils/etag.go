@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VersionETag is the ETag of an entity with a version column.
+func VersionETag(version int) string {
+	return `"v` + strconv.Itoa(version) + `"`
+}
+
+// CheckIfMatch requires an If-Match header that matches the etag of entity.
+// Writes without it are refused with 428, outdated ones with 412.
+func CheckIfMatch(r *http.Request, entity string, etag string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return exceptions.NewPreconditionRequiredError()
+	}
+
+	// Weak tags never match If-Match
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return nil
+		}
+	}
+	return exceptions.NewPreconditionFailedError(entity)
+}
+
+// NotModified reports whether the If-None-Match header already has etag.
+func NotModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJsonWithETag writes v with its ETag, or 304 when a GET or HEAD
+// request already has it.
+func WriteJsonWithETag(w http.ResponseWriter, r *http.Request, status int, v any, etag string) {
+	w.Header().Set("ETag", etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && NotModified(r, etag) {
+		WriteJson(w, http.StatusNotModified, nil)
+		return
+	}
+	WriteJson(w, status, v)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// ResizeSquare crops the centered square of img and scales it to size×size.
+// Downscaling averages the source pixels under each target pixel, so photos
+// don't come out grainy.
+func ResizeSquare(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0 := y0 + y*side/size
+		sy1 := max(y0+(y+1)*side/size, sy0+1)
+
+		for x := 0; x < size; x++ {
+			sx0 := x0 + x*side/size
+			sx1 := max(x0+(x+1)*side/size, sx0+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResizeSquare(t *testing.T) {
+	// 40x20 image, left half black and right half white
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if x >= 20 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	dst := ResizeSquare(src, 4)
+	if dst.Bounds().Dx() != 4 || dst.Bounds().Dy() != 4 {
+		t.Fatalf("expected 4x4 image, got %v", dst.Bounds())
+	}
+
+	// The centered square spans x 10..29, half black and half white
+	left, right := dst.RGBAAt(0, 0), dst.RGBAAt(3, 0)
+	if left.R != 0 || right.R != 255 {
+		t.Errorf("expected black on the left and white on the right, got %v and %v", left, right)
+	}
+
+	if up := ResizeSquare(image.NewRGBA(image.Rect(0, 0, 2, 2)), 8); up.Bounds().Dx() != 8 {
+		t.Errorf("expected small images to be scaled up, got %v", up.Bounds())
+	}
+}
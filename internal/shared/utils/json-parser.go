@@ -2,22 +2,82 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
+	"go-restaurant-management/config"
 	"go-restaurant-management/internal/shared/errors"
 	"go-restaurant-management/internal/shared/errors/exceptions"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
 )
 
+type JsonOptions struct {
+	MaxBytes              int64
+	DisallowUnknownFields bool
+}
+
+// DefaultJsonOptions reads the limits from JSON_MAX_BODY_BYTES and
+// JSON_DISALLOW_UNKNOWN_FIELDS.
+func DefaultJsonOptions() JsonOptions {
+	return JsonOptions{
+		MaxBytes:              config.Envs.JSON_MAX_BODY_BYTES,
+		DisallowUnknownFields: config.Envs.JSON_DISALLOW_UNKNOWN_FIELDS,
+	}
+}
+
+// ParseJson decodes a single JSON value from the body with DefaultJsonOptions.
+// Errors are AppErrors: 413 for large bodies, 415 for other content types and
+// INVALID_JSON for the rest.
 func ParseJson(r *http.Request, payload any) error {
-	if r.Body == nil {
-		return fmt.Errorf("parser: missing request body")
+	return ParseJsonWith(r, payload, DefaultJsonOptions())
+}
+
+func ParseJsonWith(r *http.Request, payload any, options JsonOptions) error {
+	// A missing Content-Type is accepted, some clients leave it out
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !isJsonContentType(contentType) {
+		return exceptions.NewUnsupportedMediaTypeError(contentType, "application/json")
 	}
-	return json.NewDecoder(r.Body).Decode(payload)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return exceptions.NewInvalidJSONError(io.EOF)
+	}
+
+	// One byte over the limit tells a body of exactly MaxBytes from a larger one
+	body := &io.LimitedReader{R: r.Body, N: options.MaxBytes + 1}
+	decoder := json.NewDecoder(body)
+	if options.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(payload)
+	if body.N <= 0 {
+		return exceptions.NewPayloadTooLargeError(options.MaxBytes)
+	}
+	if err != nil {
+		return exceptions.NewInvalidJSONError(err)
+	}
+
+	if err := decoder.Decode(&json.RawMessage{}); err != io.EOF {
+		if body.N <= 0 {
+			return exceptions.NewPayloadTooLargeError(options.MaxBytes)
+		}
+		return exceptions.NewInvalidJSONError(exceptions.ErrMultipleJSONValues)
+	}
+
+	return nil
+}
+
+func isJsonContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
 
 func ParseAndValidateJson(r *http.Request, payload any) error {
 	if err := ParseJson(r, payload); err != nil {
-		return exceptions.NewInvalidJSONError(err)
+		return err
 	}
 
 	if err := ValidateStruct(payload); err != nil {
@@ -45,6 +105,7 @@ func WriteJson(w http.ResponseWriter, status int, v any) {
 
 func WriteError(w http.ResponseWriter, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
+		writeErrorHeaders(w, appErr)
 		WriteJson(w, appErr.HTTPStatusCode(), appErr)
 		return
 	}
@@ -61,6 +122,7 @@ func WriteError(w http.ResponseWriter, err error) {
 
 func WriteErrorWithStatus(w http.ResponseWriter, status int, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
+		writeErrorHeaders(w, appErr)
 		WriteJson(w, status, appErr)
 		return
 	}
@@ -75,3 +137,9 @@ func WriteErrorWithStatus(w http.ResponseWriter, status int, err error) {
 	}
 	WriteJson(w, status, genericError)
 }
+
+func writeErrorHeaders(w http.ResponseWriter, appErr *errors.AppError) {
+	for key, value := range appErr.Headers {
+		w.Header().Set(key, value)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"go-restaurant-management/internal/shared/errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseJsonWith(t *testing.T) {
+	type payload struct {
+		Name     string `json:"name"`
+		Quantity int    `json:"quantity"`
+	}
+	options := JsonOptions{MaxBytes: 64, DisallowUnknownFields: true}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		wantStatus  int
+		wantReason  string
+		wantField   string
+	}{
+		{"should accept a valid body", `{"name": "Feijoada", "quantity": 2}`, "application/json; charset=utf-8", 0, "", ""},
+		{"should accept a missing content type", `{"name": "Feijoada"}`, "", 0, "", ""},
+		{"should reject other content types", `name=Feijoada`, "application/x-www-form-urlencoded", 415, "", ""},
+		{"should reject bodies over the limit", `{"name": "` + strings.Repeat("a", 64) + `"}`, "application/json", 413, "", ""},
+		{"should reject unknown fields", `{"name": "Feijoada", "price": 10}`, "application/json", 400, "The field price is not allowed", "price"},
+		{"should report the field with the wrong type", `{"quantity": "two"}`, "application/json", 400, "The field quantity must be an integer", "quantity"},
+		{"should report where the syntax breaks", `{"name": "Feijoada",}`, "application/json", 400, "The request body has malformed JSON at byte 21", ""},
+		{"should reject more than one value", `{"name": "Feijoada"} {"name": "Moqueca"}`, "application/json", 400, "The request body must contain a single JSON value", ""},
+		{"should reject an empty body", ``, "application/json", 400, "The request body is empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			err := ParseJsonWith(r, &payload{}, options)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			appErr, ok := err.(*errors.AppError)
+			if !ok {
+				t.Fatalf("expected an AppError, got %v", err)
+			}
+			if appErr.HTTPStatusCode() != tt.wantStatus {
+				t.Errorf("got status %d, want %d", appErr.HTTPStatusCode(), tt.wantStatus)
+			}
+			if tt.wantReason != "" && appErr.Details["reason"] != tt.wantReason {
+				t.Errorf("got reason %q, want %q", appErr.Details["reason"], tt.wantReason)
+			}
+			if tt.wantField != "" && appErr.Details["field"] != tt.wantField {
+				t.Errorf("got field %v, want %q", appErr.Details["field"], tt.wantField)
+			}
+		})
+	}
+}
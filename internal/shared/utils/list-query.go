@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListSpec describes what a list endpoint accepts. Only the sort fields and
+// filters listed here reach the SQL, always through placeholders.
+type ListSpec struct {
+	DefaultLimit int
+	MaxLimit     int
+	// Sorts maps the accepted sort fields to their columns
+	Sorts map[string]string
+	// DefaultSort is a sort field, prefixed with "-" for descending order
+	DefaultSort string
+	// KeyColumn is a unique column, it breaks ties between equal sort values
+	KeyColumn string
+	Filters   map[string]Filter
+	// Cursor switches from page numbers to keyset pagination, which stays
+	// fast on big tables and doesn't skip rows inserted between requests.
+	Cursor bool
+}
+
+type Filter struct {
+	// Columns are matched with OR, e.g. first_name and last_name for a name
+	Columns []string
+	// Contains matches with LIKE instead of equality
+	Contains bool
+	// Allowed lists the accepted values, any value is accepted when empty
+	Allowed []string
+	// Conditions replaces the column match with a fixed condition per value,
+	// e.g. "active": "deactivated_at IS NULL"
+	Conditions map[string]string
+}
+
+// ListQuery is a validated list request, see ParseListQuery.
+type ListQuery struct {
+	Limit int
+	// Page starts at 1, it is 0 with cursor pagination
+	Page       int
+	Sort       string
+	Descending bool
+	Filters    map[string]string
+
+	cursor *listCursor
+	spec   ListSpec
+}
+
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	Key   any    `json:"k"`
+}
+
+// ParseListQuery reads limit, page or cursor, sort and the filters of spec
+// from the query string.
+func ParseListQuery(r *http.Request, spec ListSpec) (ListQuery, error) {
+	values := r.URL.Query()
+	query := ListQuery{Limit: spec.DefaultLimit, Filters: map[string]string{}, spec: spec}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > spec.MaxLimit {
+			return ListQuery{}, exceptions.NewValidationError("limit", "The field limit must be between 1 and "+strconv.Itoa(spec.MaxLimit))
+		}
+		query.Limit = limit
+	}
+
+	sortField := values.Get("sort")
+	if sortField == "" {
+		sortField = spec.DefaultSort
+	}
+	query.Sort, query.Descending = strings.TrimPrefix(sortField, "-"), strings.HasPrefix(sortField, "-")
+	if _, ok := spec.Sorts[query.Sort]; query.Sort != "" && !ok {
+		return ListQuery{}, exceptions.NewValidationError("sort", "The field sort must be one of "+strings.Join(sortedKeys(spec.Sorts), ", "))
+	}
+
+	if spec.Cursor {
+		if values.Has("page") {
+			return ListQuery{}, exceptions.NewValidationError("page", "The field page is not supported, use cursor")
+		}
+		if value := values.Get("cursor"); value != "" {
+			cursor, err := decodeCursor(value)
+			if err != nil || cursor.Sort != sortField {
+				return ListQuery{}, exceptions.NewValidationError("cursor", "The field cursor is invalid or doesn't match the sort")
+			}
+			query.cursor = &cursor
+		}
+	} else {
+		if values.Has("cursor") {
+			return ListQuery{}, exceptions.NewValidationError("cursor", "The field cursor is not supported, use page")
+		}
+		query.Page = 1
+		if value := values.Get("page"); value != "" {
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return ListQuery{}, exceptions.NewValidationError("page", "The field page must be a positive number")
+			}
+			query.Page = page
+		}
+	}
+
+	for name, filter := range spec.Filters {
+		value := strings.TrimSpace(values.Get(name))
+		if value == "" {
+			continue
+		}
+		if len(filter.Allowed) > 0 && !slices.Contains(filter.Allowed, value) {
+			return ListQuery{}, exceptions.NewValidationError(name, "The field "+name+" must be one of "+strings.Join(filter.Allowed, ", "))
+		}
+		if _, ok := filter.Conditions[value]; filter.Conditions != nil && !ok {
+			return ListQuery{}, exceptions.NewValidationError(name, "The field "+name+" must be one of "+strings.Join(sortedKeys(filter.Conditions), ", "))
+		}
+		query.Filters[name] = value
+	}
+
+	return query, nil
+}
+
+// WhereSQL returns the WHERE clause of the filters, without the cursor
+// condition, so it also fits counts. It is empty without filters.
+func (q ListQuery) WhereSQL() (string, []any) {
+	conditions, args := q.filterConditions()
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// SQL returns the WHERE, ORDER BY and LIMIT clauses of the query. One row
+// more than the limit is fetched, NewPage uses it to know if there is a next
+// page.
+func (q ListQuery) SQL() (string, []any) {
+	conditions, args := q.filterConditions()
+
+	direction, comparison := "ASC", ">"
+	if q.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	key := q.spec.KeyColumn
+	order := key + " " + direction
+	if column, ok := q.spec.Sorts[q.Sort]; ok && column != key {
+		order = column + " " + direction + ", " + order
+		if q.cursor != nil {
+			conditions = append(conditions, "("+column+" "+comparison+" ? OR ("+column+" = ? AND "+key+" "+comparison+" ?))")
+			args = append(args, q.cursor.Value, q.cursor.Value, q.cursor.Key)
+		}
+	} else if q.cursor != nil {
+		conditions = append(conditions, key+" "+comparison+" ?")
+		args = append(args, q.cursor.Key)
+	}
+
+	clauses := ""
+	if len(conditions) > 0 {
+		clauses = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	clauses += " ORDER BY " + order + " LIMIT ?"
+	args = append(args, q.Limit+1)
+
+	if q.Page > 1 {
+		clauses += " OFFSET ?"
+		args = append(args, (q.Page-1)*q.Limit)
+	}
+
+	return clauses, args
+}
+
+func (q ListQuery) filterConditions() ([]string, []any) {
+	var conditions []string
+	var args []any
+
+	for _, name := range sortedKeys(q.Filters) {
+		filter, value := q.spec.Filters[name], q.Filters[name]
+		if condition, ok := filter.Conditions[value]; ok {
+			conditions = append(conditions, condition)
+			continue
+		}
+
+		matches := make([]string, 0, len(filter.Columns))
+		for _, column := range filter.Columns {
+			if filter.Contains {
+				matches = append(matches, column+" LIKE ?")
+				args = append(args, "%"+escapeLike(value)+"%")
+			} else {
+				matches = append(matches, column+" = ?")
+				args = append(args, value)
+			}
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+
+	return conditions, args
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+type Page[T any] struct {
+	Data  []T       `json:"data"`
+	Meta  PageMeta  `json:"meta"`
+	Links PageLinks `json:"links"`
+}
+
+type PageMeta struct {
+	Limit       int    `json:"limit"`
+	Page        int    `json:"page,omitempty"`
+	Total       *int   `json:"total,omitempty"`
+	Next_cursor string `json:"next_cursor,omitempty"`
+}
+
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// NewPage builds the response of a list from the rows fetched with q.SQL.
+// total may be nil when it wasn't counted. cursorOf returns the sort value
+// and the key of an item and is only needed with cursor pagination.
+func NewPage[T any](r *http.Request, q ListQuery, items []T, total *int, cursorOf func(item T) (any, any)) Page[T] {
+	hasNext := len(items) > q.Limit
+	if hasNext {
+		items = items[:q.Limit]
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	page := Page[T]{Data: items, Meta: PageMeta{Limit: q.Limit, Page: q.Page, Total: total}}
+
+	if q.spec.Cursor {
+		if hasNext && cursorOf != nil {
+			value, key := cursorOf(items[len(items)-1])
+			sortField := q.Sort
+			if q.Descending {
+				sortField = "-" + sortField
+			}
+			page.Meta.Next_cursor = encodeCursor(listCursor{Sort: sortField, Value: cursorValue(value), Key: cursorValue(key)})
+			page.Links.Next = linkWith(r, "cursor", page.Meta.Next_cursor)
+		}
+		return page
+	}
+
+	if hasNext {
+		page.Links.Next = linkWith(r, "page", strconv.Itoa(q.Page+1))
+	}
+	if q.Page > 1 {
+		page.Links.Prev = linkWith(r, "page", strconv.Itoa(q.Page-1))
+	}
+	return page
+}
+
+// cursorValue keeps times in the format MySQL compares with DATETIME columns.
+func cursorValue(value any) any {
+	if t, ok := value.(time.Time); ok {
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return value
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(cursor listCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return listCursor{}, err
+	}
+
+	var cursor listCursor
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return listCursor{}, err
+	}
+	// Numbers are kept as text, MySQL converts them when comparing
+	if number, ok := cursor.Value.(json.Number); ok {
+		cursor.Value = number.String()
+	}
+	if number, ok := cursor.Key.(json.Number); ok {
+		cursor.Key = number.String()
+	}
+
+	if _, ok := cursor.Key.(string); !ok {
+		return listCursor{}, errInvalidCursor
+	}
+	if _, ok := cursor.Value.(string); cursor.Value != nil && !ok {
+		return listCursor{}, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+func linkWith(r *http.Request, param string, value string) string {
+	values := r.URL.Query()
+	values.Set(param, value)
+	link := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return link.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testOrderSpec = ListSpec{
+	DefaultLimit: 2,
+	MaxLimit:     50,
+	Sorts:        map[string]string{"created_at": "created_at", "id": "id"},
+	DefaultSort:  "-created_at",
+	KeyColumn:    "id",
+	Filters: map[string]Filter{
+		"status": {Columns: []string{"status"}, Allowed: []string{"open", "paid"}},
+		"note":   {Columns: []string{"note"}, Contains: true},
+	},
+	Cursor: true,
+}
+
+type testOrder struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+func TestParseListQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"should accept the defaults", "", false},
+		{"should accept known filters and sorts", "?status=open&sort=id&limit=50", false},
+		{"should reject an unknown sort field", "?sort=total", true},
+		{"should reject a value outside the allowed ones", "?status=cancelled", true},
+		{"should reject a limit above the maximum", "?limit=51", true},
+		{"should reject page numbers on cursor lists", "?page=2", true},
+		{"should reject a malformed cursor", "?cursor=not-a-cursor", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseListQuery(httptest.NewRequest("GET", "/api/orders"+tt.query, nil), testOrderSpec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseListQuery(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListQuerySQL(t *testing.T) {
+	t.Run("should escape LIKE wildcards in contains filters", func(t *testing.T) {
+		query, err := ParseListQuery(httptest.NewRequest("GET", "/api/orders?note=50%25_off&status=paid", nil), testOrderSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clauses, args := query.SQL()
+		wantClauses := " WHERE (note LIKE ?) AND (status = ?) ORDER BY created_at DESC, id DESC LIMIT ?"
+		if clauses != wantClauses {
+			t.Errorf("got %q, want %q", clauses, wantClauses)
+		}
+		if want := []any{`%50\%\_off%`, "paid", 3}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
+		}
+	})
+
+	t.Run("should continue after the cursor of the last item", func(t *testing.T) {
+		first, err := ParseListQuery(httptest.NewRequest("GET", "/api/orders", nil), testOrderSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		createdAt := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+		items := []testOrder{{ID: 9, CreatedAt: createdAt.Add(time.Hour)}, {ID: 7, CreatedAt: createdAt}, {ID: 5, CreatedAt: createdAt}}
+		page := NewPage(httptest.NewRequest("GET", "/api/orders", nil), first, items, nil, func(o testOrder) (any, any) {
+			return o.CreatedAt, o.ID
+		})
+		if len(page.Data) != 2 || page.Meta.Next_cursor == "" {
+			t.Fatalf("expected 2 items and a next cursor, got %+v", page)
+		}
+
+		next, err := ParseListQuery(httptest.NewRequest("GET", page.Links.Next, nil), testOrderSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clauses, args := next.SQL()
+		wantClauses := " WHERE (created_at < ? OR (created_at = ? AND id < ?)) ORDER BY created_at DESC, id DESC LIMIT ?"
+		if clauses != wantClauses {
+			t.Errorf("got %q, want %q", clauses, wantClauses)
+		}
+		if want := []any{"2026-10-19 12:30:00", "2026-10-19 12:30:00", "7", 3}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
+		}
+	})
+
+	t.Run("should reject a cursor made for another sort", func(t *testing.T) {
+		first, _ := ParseListQuery(httptest.NewRequest("GET", "/api/orders", nil), testOrderSpec)
+		page := NewPage(httptest.NewRequest("GET", "/api/orders", nil), first, []testOrder{{ID: 3}, {ID: 2}, {ID: 1}}, nil, func(o testOrder) (any, any) {
+			return o.CreatedAt, o.ID
+		})
+
+		if _, err := ParseListQuery(httptest.NewRequest("GET", "/api/orders?sort=id&cursor="+page.Meta.Next_cursor, nil), testOrderSpec); err == nil {
+			t.Error("expected the cursor to be rejected")
+		}
+	})
+}
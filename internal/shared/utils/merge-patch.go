@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// MergePatch applies an RFC 7396 merge patch onto doc, both decoded JSON
+// values. Members set to null in the patch are removed.
+func MergePatch(doc any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	docObject, ok := doc.(map[string]any)
+	if !ok {
+		docObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(docObject, key)
+			continue
+		}
+		docObject[key] = MergePatch(docObject[key], value)
+	}
+	return docObject
+}
+
+// ParseAndApplyMergePatch reads a merge patch from the body and applies it
+// onto target, a pointer to a struct, through its JSON form. Absent fields are
+// left alone and nulls reset fields to their zero value. Fields tagged
+// `patch:"readonly"` can't be patched. target only changes when the result
+// passes ValidateStruct.
+func ParseAndApplyMergePatch(r *http.Request, target any) error {
+	options := DefaultJsonOptions()
+
+	var patch json.RawMessage
+	if err := ParseJsonWith(r, &patch, options); err != nil {
+		return err
+	}
+	return ApplyMergePatch(target, patch, options.DisallowUnknownFields)
+}
+
+func ApplyMergePatch(target any, patch []byte, disallowUnknownFields bool) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("merge patch target must be a pointer to a struct, got %T", target))
+	}
+	structType := value.Elem().Type()
+
+	if !bytes.HasPrefix(bytes.TrimSpace(patch), []byte("{")) {
+		return exceptions.NewValidationError("body", "The merge patch must be a JSON object")
+	}
+
+	// Decoding the patch on its own reports wrong types and unknown fields at
+	// their position in the request body
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	if disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(reflect.New(structType).Interface()); err != nil {
+		return exceptions.NewInvalidJSONError(err)
+	}
+
+	var patchDoc map[string]any
+	if err := decodeNumbers(patch, &patchDoc); err != nil {
+		return exceptions.NewInvalidJSONError(err)
+	}
+
+	// Keys match fields regardless of case, like the decoder does. They are
+	// renamed to the JSON name of their field, otherwise a "First_Name" would
+	// sit next to the current "first_name" and lose to it when decoding.
+	fields := jsonFields(structType)
+	normalized := make(map[string]any, len(patchDoc))
+	for key, value := range patchDoc {
+		name := fieldName(fields, key)
+		if _, ok := normalized[name]; ok {
+			return exceptions.NewValidationError(name, "The field "+name+" is present more than once")
+		}
+		if field, ok := fields[name]; ok && field.Tag.Get("patch") == "readonly" {
+			return exceptions.NewValidationError(name, "The field "+name+" can't be changed")
+		}
+		normalized[name] = value
+	}
+	patchDoc = normalized
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+	var doc map[string]any
+	if err := decodeNumbers(current, &doc); err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+
+	// Fields in the JSON form are reset so removed members end up as zero
+	// values. The others, like `json:"-"` ones, are kept as they are.
+	result := reflect.New(structType)
+	result.Elem().Set(value.Elem())
+	for key, field := range fields {
+		if _, ok := doc[key]; ok {
+			result.Elem().FieldByIndex(field.Index).SetZero()
+		}
+	}
+
+	merged, err := json.Marshal(MergePatch(doc, patchDoc))
+	if err != nil {
+		return exceptions.NewInternalServerError(err.Error())
+	}
+	if err := json.Unmarshal(merged, result.Interface()); err != nil {
+		return exceptions.NewInvalidJSONError(err)
+	}
+
+	if err := ValidateStruct(result.Interface()); err != nil {
+		return err
+	}
+
+	value.Elem().Set(result.Elem())
+	return nil
+}
+
+// decodeNumbers keeps numbers as json.Number, float64 would round large IDs.
+func decodeNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// fieldName returns the JSON name of the field key refers to, preferring an
+// exact match. Unknown keys are returned as they are.
+func fieldName(fields map[string]reflect.StructField, key string) string {
+	if _, ok := fields[key]; ok {
+		return key
+	}
+	for name := range fields {
+		if strings.EqualFold(key, name) {
+			return name
+		}
+	}
+	return key
+}
+
+// jsonFields maps the JSON names of the exported top level fields of a struct
+// to the fields.
+func jsonFields(structType reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"go-restaurant-management/internal/shared/errors"
+	"reflect"
+	"testing"
+)
+
+type testFood struct {
+	ID          uint64   `json:"id" patch:"readonly"`
+	Name        string   `json:"name" validate:"required,min=3,max=100"`
+	Description string   `json:"description" validate:"omitempty,min=3,max=255"`
+	Price       float64  `json:"price" validate:"required,min=0"`
+	Tags        []string `json:"tags"`
+	Internal    string   `json:"-"`
+}
+
+func TestMergePatch(t *testing.T) {
+	doc := map[string]any{"a": "b", "c": map[string]any{"d": "e", "f": "g"}}
+	patch := map[string]any{"a": "z", "c": map[string]any{"f": nil}}
+
+	want := map[string]any{"a": "z", "c": map[string]any{"d": "e"}}
+	if got := MergePatch(doc, patch); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergePatch() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	original := testFood{ID: 3, Name: "Feijoada", Description: "Black bean stew", Price: 59.9, Tags: []string{"pork"}, Internal: "kept"}
+
+	tests := []struct {
+		name       string
+		patch      string
+		want       testFood
+		wantReason string
+	}{
+		{
+			"should leave absent fields alone",
+			`{"price": 64.9}`,
+			testFood{ID: 3, Name: "Feijoada", Description: "Black bean stew", Price: 64.9, Tags: []string{"pork"}, Internal: "kept"},
+			"",
+		},
+		{
+			"should clear fields set to null",
+			`{"description": null, "tags": null}`,
+			testFood{ID: 3, Name: "Feijoada", Price: 59.9, Internal: "kept"},
+			"",
+		},
+		{
+			"should apply fields named in another case",
+			`{"Name": "Moqueca"}`,
+			testFood{ID: 3, Name: "Moqueca", Description: "Black bean stew", Price: 59.9, Tags: []string{"pork"}, Internal: "kept"},
+			"",
+		},
+		{"should refuse the same field twice", `{"name": "Moqueca", "NAME": "Vatapá"}`, original, "The field name is present more than once"},
+		{"should refuse read only fields", `{"ID": 9}`, original, "The field id can't be changed"},
+		{"should validate the result", `{"name": null}`, original, "The field name is required"},
+		{"should refuse patches that are not objects", `["name"]`, original, "The merge patch must be a JSON object"},
+		{"should refuse unknown fields", `{"calories": 800}`, original, "The field calories is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			food := original
+			food.Tags = append([]string(nil), original.Tags...)
+
+			err := ApplyMergePatch(&food, []byte(tt.patch), true)
+			if tt.wantReason != "" {
+				appErr, ok := err.(*errors.AppError)
+				if !ok || appErr.Details["reason"] != tt.wantReason {
+					t.Errorf("got error %v, want reason %q", err, tt.wantReason)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(food, tt.want) {
+				t.Errorf("got %+v, want %+v", food, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"errors"
+	"go-restaurant-management/internal/shared/errors/exceptions"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// The Path helpers read route variables, which are always required. The Query
+// helpers return def when the parameter is absent or empty.
+
+func PathUint64(r *http.Request, name string) (uint64, error) {
+	value, err := pathParam(r, name)
+	if err != nil {
+		return 0, err
+	}
+	return parseUint64(name, value)
+}
+
+func PathInt(r *http.Request, name string) (int, error) {
+	value, err := pathParam(r, name)
+	if err != nil {
+		return 0, err
+	}
+	return parseInt(name, value)
+}
+
+// PathUUID returns the UUID in lowercase.
+func PathUUID(r *http.Request, name string) (string, error) {
+	value, err := pathParam(r, name)
+	if err != nil {
+		return "", err
+	}
+	return parseUUID(name, value)
+}
+
+func QueryUint64(r *http.Request, name string, def uint64) (uint64, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+	return parseUint64(name, value)
+}
+
+func QueryInt(r *http.Request, name string, def int) (int, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+	return parseInt(name, value)
+}
+
+// QueryBool accepts true/false, 1/0 and the other forms of strconv.ParseBool.
+func QueryBool(r *http.Request, name string, def bool) (bool, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, exceptions.NewValidationError(name, "The parameter "+name+" must be true or false")
+	}
+	return parsed, nil
+}
+
+// QueryTime accepts RFC 3339 timestamps and plain dates, which are taken as
+// midnight UTC.
+func QueryTime(r *http.Request, name string, def time.Time) (time.Time, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, exceptions.NewValidationError(name, "The parameter "+name+" must be a date like 2026-10-19 or a timestamp like 2026-10-19T15:04:05Z")
+}
+
+func QueryEnum(r *http.Request, name string, def string, allowed ...string) (string, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+
+	if !slices.Contains(allowed, value) {
+		return "", exceptions.NewValidationError(name, "The parameter "+name+" must be one of "+strings.Join(allowed, ", "))
+	}
+	return value, nil
+}
+
+func QueryUUID(r *http.Request, name string, def string) (string, error) {
+	value := queryParam(r, name)
+	if value == "" {
+		return def, nil
+	}
+	return parseUUID(name, value)
+}
+
+func pathParam(r *http.Request, name string) (string, error) {
+	value, ok := mux.Vars(r)[name]
+	if !ok || value == "" {
+		return "", exceptions.NewValidationError(name, "The parameter "+name+" is required")
+	}
+	return value, nil
+}
+
+func queryParam(r *http.Request, name string) string {
+	return strings.TrimSpace(r.URL.Query().Get(name))
+}
+
+func parseUint64(name string, value string) (uint64, error) {
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, exceptions.NewValidationError(name, "The parameter "+name+" is out of range")
+		}
+		return 0, exceptions.NewValidationError(name, "The parameter "+name+" must be a positive integer")
+	}
+	return parsed, nil
+}
+
+func parseInt(name string, value string) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, exceptions.NewValidationError(name, "The parameter "+name+" is out of range")
+		}
+		return 0, exceptions.NewValidationError(name, "The parameter "+name+" must be an integer")
+	}
+	return parsed, nil
+}
+
+func parseUUID(name string, value string) (string, error) {
+	value = strings.ToLower(value)
+	if !uuidPattern.MatchString(value) {
+		return "", exceptions.NewValidationError(name, "The parameter "+name+" must be a UUID")
+	}
+	return value, nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"go-restaurant-management/internal/shared/errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   uint64
+		reason string
+	}{
+		{"should parse an ID", "42", 42, ""},
+		{"should parse the largest ID", "18446744073709551615", 18446744073709551615, ""},
+		{"should report a missing parameter", "", 0, "The parameter id is required"},
+		{"should report a malformed value", "abc", 0, "The parameter id must be a positive integer"},
+		{"should report a negative value", "-1", 0, "The parameter id must be a positive integer"},
+		{"should report an overflow", "18446744073709551616", 0, "The parameter id is out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": tt.value})
+
+			got, err := PathUint64(r, "id")
+			if tt.reason == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("PathUint64(%q) = (%d, %v), want %d", tt.value, got, err, tt.want)
+				}
+				return
+			}
+
+			appErr, ok := err.(*errors.AppError)
+			if !ok || appErr.Code != "VALIDATION_ERROR" || appErr.Details["reason"] != tt.reason {
+				t.Errorf("PathUint64(%q) error = %v, want reason %q", tt.value, err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?paid=1&from=2026-10-19&status=open&table=7", nil)
+
+	if paid, err := QueryBool(r, "paid", false); err != nil || !paid {
+		t.Errorf("QueryBool = (%v, %v), want true", paid, err)
+	}
+	if from, err := QueryTime(r, "from", time.Time{}); err != nil || !from.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("QueryTime = (%v, %v)", from, err)
+	}
+	if _, err := QueryEnum(r, "status", "", "paid", "cancelled"); err == nil {
+		t.Error("expected QueryEnum to reject a value outside the allowed ones")
+	}
+	if limit, err := QueryInt(r, "limit", 20); err != nil || limit != 20 {
+		t.Errorf("QueryInt = (%d, %v), want the default 20", limit, err)
+	}
+	if table, err := QueryUint64(r, "table", 0); err != nil || table != 7 {
+		t.Errorf("QueryUint64 = (%d, %v), want 7", table, err)
+	}
+	if _, err := QueryUUID(httptest.NewRequest("GET", "/?ref=123", nil), "ref", ""); err == nil {
+		t.Error("expected QueryUUID to reject a malformed UUID")
+	}
+}
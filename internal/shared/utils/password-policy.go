@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"go-restaurant-management/config"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// bcrypt ignores everything after the 72nd byte, so longer passwords would
+// silently match any password sharing the same prefix.
+const PasswordMaxBytes = 72
+
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     int(config.Envs.PASSWORD_MIN_LENGTH),
+	RequireUpper:  config.Envs.PASSWORD_REQUIRE_UPPER,
+	RequireLower:  config.Envs.PASSWORD_REQUIRE_LOWER,
+	RequireDigit:  config.Envs.PASSWORD_REQUIRE_DIGIT,
+	RequireSymbol: config.Envs.PASSWORD_REQUIRE_SYMBOL,
+}
+
+//go:embed common-passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
+
+// Satisfies reports whether password meets the length and character class rules.
+func (p PasswordPolicy) Satisfies(password string) bool {
+	if len([]rune(password)) < p.MinLength || len(password) > PasswordMaxBytes {
+		return false
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	return (!p.RequireUpper || upper) &&
+		(!p.RequireLower || lower) &&
+		(!p.RequireDigit || digit) &&
+		(!p.RequireSymbol || symbol)
+}
+
+// Describe returns the policy in words, used in validation messages.
+func (p PasswordPolicy) Describe() string {
+	var classes []string
+	if p.RequireUpper {
+		classes = append(classes, "an uppercase letter")
+	}
+	if p.RequireLower {
+		classes = append(classes, "a lowercase letter")
+	}
+	if p.RequireDigit {
+		classes = append(classes, "a digit")
+	}
+	if p.RequireSymbol {
+		classes = append(classes, "a symbol")
+	}
+
+	description := fmt.Sprintf("between %d characters and %d bytes", p.MinLength, PasswordMaxBytes)
+	if len(classes) > 0 {
+		description += " and contain at least " + strings.Join(classes, ", ")
+	}
+	return description
+}
+
+func IsCommonPassword(password string) bool {
+	_, found := commonPasswords[strings.ToLower(password)]
+	return found
+}
+
+// ContainsPersonalInfo reports whether password contains any of the given
+// values (names, the local part of an email), ignoring case. Values shorter
+// than three characters are too likely to match by accident and are skipped.
+func ContainsPersonalInfo(password string, values ...string) bool {
+	lowered := strings.ToLower(password)
+	for _, value := range values {
+		if at := strings.Index(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) >= 3 && strings.Contains(lowered, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePasswordPolicy(fl validator.FieldLevel) bool {
+	return DefaultPasswordPolicy.Satisfies(fl.Field().String())
+}
+
+func validatePasswordCommon(fl validator.FieldLevel) bool {
+	return !IsCommonPassword(fl.Field().String())
+}
+
+// validatePasswordPersonal reads the sibling fields named in the tag param,
+// e.g. `validate:"password_personal=Email First_name Last_name"`.
+func validatePasswordPersonal(fl validator.FieldLevel) bool {
+	parent := fl.Parent()
+	if parent.Kind() == reflect.Ptr {
+		parent = parent.Elem()
+	}
+
+	var values []string
+	for _, name := range strings.Fields(fl.Param()) {
+		field := parent.FieldByName(name)
+		if field.IsValid() && field.Kind() == reflect.String {
+			values = append(values, field.String())
+		}
+	}
+
+	return !ContainsPersonalInfo(fl.Field().String(), values...)
+}
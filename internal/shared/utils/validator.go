@@ -19,6 +19,20 @@ func init() {
 		}
 		return name
 	})
+
+	Validate.RegisterValidation("br_mobile", func(fl validator.FieldLevel) bool {
+		return IsValidBrazilianMobile(fl.Field().String())
+	})
+	Validate.RegisterValidation("cpf", func(fl validator.FieldLevel) bool {
+		return IsValidCPF(fl.Field().String())
+	})
+	Validate.RegisterValidation("cnpj", func(fl validator.FieldLevel) bool {
+		return IsValidCNPJ(fl.Field().String())
+	})
+
+	Validate.RegisterValidation("password_policy", validatePasswordPolicy)
+	Validate.RegisterValidation("password_common", validatePasswordCommon)
+	Validate.RegisterValidation("password_personal", validatePasswordPersonal)
 }
 
 func ValidateStruct(s interface{}) error {
@@ -70,8 +84,22 @@ func getValidationMessage(err validator.FieldError) string {
 		return fmt.Sprintf("The field %s must have at most %s characters", field, err.Param())
 	case "len":
 		return fmt.Sprintf("The field %s must have exactly %s characters", field, err.Param())
-	case "numeric":
-		return fmt.Sprintf("The field %s must contain only numbers", field)
+	case "number":
+		return fmt.Sprintf("The field %s must contain only digits", field)
+	case "br_mobile":
+		return fmt.Sprintf("The field %s must be a valid Brazilian mobile number with area code, e.g. (11) 98765-4321", field)
+	case "cpf":
+		return fmt.Sprintf("The field %s must be a valid CPF", field)
+	case "cnpj":
+		return fmt.Sprintf("The field %s must be a valid CNPJ", field)
+	case "cpf|cnpj":
+		return fmt.Sprintf("The field %s must be a valid CPF or CNPJ", field)
+	case "password_policy":
+		return fmt.Sprintf("The field %s must have %s", field, DefaultPasswordPolicy.Describe())
+	case "password_common":
+		return fmt.Sprintf("The field %s is too common and appears in leaked password lists", field)
+	case "password_personal":
+		return fmt.Sprintf("The field %s must not contain your name or email", field)
 	case "eqfield":
 		return fmt.Sprintf("The field %s must be equal to %s", field, err.Param())
 	default: